@@ -10,10 +10,12 @@ import (
 
 func main() {
 	serverAddr := flag.String("server", "localhost:50051", "The server address in the format of host:port")
+	appName := flag.String("app-name", "user-client", "Application name sent in the gRPC user-agent")
+	appVersion := flag.String("app-version", "dev", "Application version sent in the gRPC user-agent")
 	flag.Parse()
 
 	// 클라이언트 생성
-	userClient, err := client.NewUserClient(*serverAddr)
+	userClient, err := client.NewUserClient(*serverAddr, client.WithUserAgent(*appName, *appVersion))
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
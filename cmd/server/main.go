@@ -3,14 +3,23 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 
 	"go-grpc-server-client/internal/server"
 )
 
 func main() {
 	port := flag.Int("port", 50051, "The server port")
+	selfCheck := flag.Bool("selfcheck", false, "Check MySQL, the lock backend and the schema, print a report and exit")
 	flag.Parse()
 
+	if *selfCheck {
+		if err := server.SelfCheck(os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Printf("Starting gRPC server on port %d", *port)
 	
 	if err := server.RunServer(*port); err != nil {
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNewUserClient_TokenSource(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	recordAuth := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		mu.Lock()
+		seen = append(seen, md.Get("authorization")...)
+		mu.Unlock()
+		return handler(ctx, req)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.UnaryInterceptor(recordAuth))
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	// A refreshing source: every call gets a new token
+	var calls int
+	source := func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return fmt.Sprintf("token-%d", calls), nil
+	}
+
+	c, err := NewUserClient(lis.Addr().String(), WithTokenSource(source))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.GetUser(1)
+	require.NoError(t, err)
+	_, err = c.GetUser(2)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, seen)
+}
+
+func TestNewUserClient_TokenSourceError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClient(lis.Addr().String(), WithTokenSource(func(ctx context.Context) (string, error) {
+		return "", fmt.Errorf("token expired")
+	}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.GetUser(1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token expired")
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"sync"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultBatchConcurrency bounds the concurrent GetUser calls of GetUsersBatch
+const defaultBatchConcurrency = 8
+
+// WithBatchConcurrency sets how many GetUser calls GetUsersBatch runs at once
+func WithBatchConcurrency(n int) Option {
+	return func(c *UserClient) {
+		c.batchConcurrency = n
+	}
+}
+
+// GetUsersBatch fetches several users with concurrent GetUser calls, at most
+// the configured batch concurrency at a time. It works against servers
+// without a bulk RPC. Each id ends up in exactly one of the returned maps:
+// users holds those fetched, errs the error of every id that failed.
+// Duplicate ids are fetched once.
+func (c *UserClient) GetUsersBatch(ids []int32) (users map[int32]*pb.User, errs map[int32]error) {
+	limit := c.batchConcurrency
+	if limit <= 0 {
+		limit = defaultBatchConcurrency
+	}
+
+	users = make(map[int32]*pb.User, len(ids))
+	errs = make(map[int32]error)
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, limit)
+		seen = make(map[int32]bool, len(ids))
+	)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int32) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			user, err := c.GetUser(id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[id] = err
+			} else {
+				users[id] = user
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	logger.WithFields(logrus.Fields{
+		"requested": len(seen),
+		"found":     len(users),
+		"failed":    len(errs),
+	}).Info("User batch retrieved")
+	return users, errs
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserClient_GetUsersBatch(t *testing.T) {
+	const limit = 3
+
+	var inFlight, maxInFlight int32
+	track := func(mock.Arguments) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}
+
+	mockClient := &MockUserServiceClient{}
+	for id := int32(1); id <= 10; id++ {
+		req := &pb.GetUserRequest{Id: id}
+		if id == 7 {
+			mockClient.On("GetUser", mock.Anything, req, mock.Anything).Run(track).Return(nil, fmt.Errorf("unavailable")).Once()
+			continue
+		}
+		mockClient.On("GetUser", mock.Anything, req, mock.Anything).Run(track).Return(&pb.GetUserResponse{
+			User:    &pb.User{Id: id, Name: fmt.Sprintf("user-%d", id)},
+			Success: true,
+		}, nil).Once()
+	}
+	client := &UserClient{client: mockClient, batchConcurrency: limit}
+
+	// 3 is requested twice but fetched once
+	ids := []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 3}
+	users, errs := client.GetUsersBatch(ids)
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(limit))
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+
+	require.Len(t, users, 9)
+	for id, u := range users {
+		assert.Equal(t, id, u.Id)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[7], "unavailable")
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_GetUsersBatch_Empty(t *testing.T) {
+	client := &UserClient{client: &MockUserServiceClient{}}
+
+	users, errs := client.GetUsersBatch(nil)
+
+	assert.Empty(t, users)
+	assert.Empty(t, errs)
+}
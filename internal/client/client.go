@@ -2,19 +2,63 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"go-grpc-server-client/internal/redact"
+	"go-grpc-server-client/internal/tlsversion"
 	pb "go-grpc-server-client/proto"
 
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
+// defaultPageSize is the ListUsers limit used when no page size is configured
+const defaultPageSize int32 = 100
+
+// pageLimitHeader carries the page size the server actually applied to ListUsers
+const pageLimitHeader = "x-page-limit"
+
 var logger = logrus.New()
 
+// useGzip compresses requests with gzip, which makes the server compress its
+// responses too (GRPC_COMPRESSION=gzip). Worth it for large ListUsers pages
+// over WAN links.
+var useGzip bool
+
+// Client-side retries of Unavailable errors (GRPC_RETRY_MAX_ATTEMPTS, counting
+// the first attempt; 1 disables retries), capped by a retry budget of
+// retryBudgetRatio of recent requests (GRPC_RETRY_BUDGET_RATIO)
+var (
+	retryMaxAttempts = 1
+	retryBudgetRatio = defaultRetryBudgetRatio
+)
+
+// idleTimeout is how long a connection may go without RPCs before it is torn
+// down; the next RPC transparently reconnects (GRPC_CLIENT_IDLE_TIMEOUT).
+// Zero keeps gRPC's default of 30 minutes.
+var idleTimeout time.Duration
+
+// tlsMinVersion is the oldest TLS version NewUserClientWithTLS negotiates
+// (TLS_MIN_VERSION)
+var tlsMinVersion uint16 = tls.VersionTLS12
+
+// tlsInsecureSkipVerify disables server certificate verification for local
+// testing against self-signed servers (TLS_INSECURE_SKIP_VERIFY). Never use
+// it in production: any server, or anyone in between, is accepted.
+var tlsInsecureSkipVerify bool
+
 func init() {
 	// Configure logrus for client
 	logger.SetFormatter(&logrus.JSONFormatter{
@@ -33,35 +77,206 @@ func init() {
 	if logger.GetLevel() == logrus.PanicLevel {
 		logger.SetLevel(logrus.InfoLevel)
 	}
+
+	switch v := strings.ToLower(os.Getenv("GRPC_COMPRESSION")); v {
+	case "":
+	case gzip.Name:
+		useGzip = true
+	default:
+		logger.WithField("grpc_compression", v).Warn("Ignoring unsupported GRPC_COMPRESSION (only gzip is supported)")
+	}
+
+	if v := os.Getenv("GRPC_RETRY_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			retryMaxAttempts = n
+		} else {
+			logger.WithField("grpc_retry_max_attempts", v).Warn("Ignoring invalid GRPC_RETRY_MAX_ATTEMPTS")
+		}
+	}
+	if v := os.Getenv("GRPC_RETRY_BUDGET_RATIO"); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r >= 0 && r <= 1 {
+			retryBudgetRatio = r
+		} else {
+			logger.WithField("grpc_retry_budget_ratio", v).Warn("Ignoring invalid GRPC_RETRY_BUDGET_RATIO (must be between 0 and 1)")
+		}
+	}
+	if v := os.Getenv("GRPC_CLIENT_IDLE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			idleTimeout = d
+		} else {
+			logger.WithField("grpc_client_idle_timeout", v).Warn("Ignoring invalid GRPC_CLIENT_IDLE_TIMEOUT")
+		}
+	}
+	if v := os.Getenv("TLS_MIN_VERSION"); v != "" {
+		if version, err := tlsversion.Parse(v); err == nil {
+			tlsMinVersion = version
+		} else {
+			logger.WithError(err).Warn("Ignoring invalid TLS_MIN_VERSION")
+		}
+	}
+	if v := os.Getenv("TLS_INSECURE_SKIP_VERIFY"); strings.ToLower(v) == "on" {
+		tlsInsecureSkipVerify = true
+	}
 }
 
+// UserClient calls the UserService. Errors returned by a failed RPC wrap its
+// gRPC status, so callers can branch on status.Code(err).
 type UserClient struct {
-	client pb.UserServiceClient
-	conn   *grpc.ClientConn
+	client    pb.UserServiceClient
+	health    healthpb.HealthClient
+	conn      *grpc.ClientConn
+	pageSize  int32
+	userAgent string
+
+	batchConcurrency int
+	maxListAllUsers  int
+
+	// Client-side input checks (WithClientValidation)
+	validate bool
+
+	tokenSource TokenSource
+
+	// Connection recycling (WithMaxRequestsPerConn)
+	maxRequestsPerConn int
+	recycler           *recyclingConn
+}
+
+// Option configures optional UserClient behaviour
+type Option func(*UserClient)
+
+// WithPageSize sets the limit ListUsers requests per page. The server may
+// clamp it to its own maximum.
+func WithPageSize(size int32) Option {
+	return func(c *UserClient) {
+		c.pageSize = size
+	}
+}
+
+// WithUserAgent identifies the calling application to the server as
+// "name/version" in the user-agent header, ahead of gRPC's own token, so
+// traffic can be attributed to specific client builds. It only takes effect
+// when passed to a constructor.
+func WithUserAgent(name, version string) Option {
+	return func(c *UserClient) {
+		c.userAgent = name
+		if version != "" {
+			c.userAgent += "/" + version
+		}
+	}
+}
+
+// NewUserClient connects without TLS; use NewUserClientWithTLS in production
+func NewUserClient(serverAddr string, opts ...Option) (*UserClient, error) {
+	logger.WithField("server_addr", serverAddr).Warn("Connecting WITHOUT TLS: traffic is plaintext")
+	return newUserClient(serverAddr, insecure.NewCredentials(), opts...)
+}
+
+// NewUserClientWithTLS connects over TLS. caFile verifies the server
+// certificate (system roots are used when empty); certFile and keyFile are the
+// client certificate presented to servers running in mutual TLS mode and may
+// be empty for plain server-side TLS.
+func NewUserClientWithTLS(serverAddr, caFile, certFile, keyFile string, opts ...Option) (*UserClient, error) {
+	tlsConfig, err := clientTLSConfig(caFile, certFile, keyFile)
+	if err != nil {
+		logger.WithError(err).WithField("server_addr", serverAddr).Error("Failed to configure TLS")
+		return nil, err
+	}
+	return newUserClient(serverAddr, credentials.NewTLS(tlsConfig), opts...)
 }
 
-func NewUserClient(serverAddr string) (*UserClient, error) {
+func newUserClient(serverAddr string, creds credentials.TransportCredentials, opts ...Option) (*UserClient, error) {
 	logger.WithField("server_addr", serverAddr).Info("Connecting to gRPC server")
 
-	conn, err := grpc.Dial(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	c := &UserClient{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if c.userAgent != "" {
+		dialOpts = append(dialOpts, grpc.WithUserAgent(c.userAgent))
+	}
+	if idleTimeout > 0 {
+		dialOpts = append(dialOpts, grpc.WithIdleTimeout(idleTimeout))
+	}
+	if c.tokenSource != nil {
+		tokenCreds := newTokenCredentials(c.tokenSource, creds)
+		if !tokenCreds.secure {
+			logger.WithField("server_addr", serverAddr).Warn("Sending auth tokens over a plaintext connection")
+		}
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCreds))
+	}
+	if useGzip {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	}
+	if retryMaxAttempts > 1 {
+		budget := newRetryBudget(retryBudgetRatio, retryBudgetReserve, retryBudgetWindow)
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(retryInterceptor(retryMaxAttempts, budget)))
+	}
+
+	conn, err := grpc.Dial(serverAddr, dialOpts...)
 	if err != nil {
 		logger.WithError(err).WithField("server_addr", serverAddr).Error("Failed to connect to gRPC server")
-		return nil, fmt.Errorf("failed to connect: %v", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
-	client := pb.NewUserServiceClient(conn)
 	logger.WithField("server_addr", serverAddr).Info("gRPC client connected successfully")
 
-	return &UserClient{
-		client: client,
-		conn:   conn,
-	}, nil
+	c.conn = conn
+	if c.maxRequestsPerConn > 0 {
+		c.recycler = newRecyclingConn(conn, c.maxRequestsPerConn, func() (*grpc.ClientConn, error) {
+			return grpc.Dial(serverAddr, dialOpts...)
+		})
+		c.client = pb.NewUserServiceClient(c.recycler)
+		c.health = healthpb.NewHealthClient(c.recycler)
+		return c, nil
+	}
+	c.client = pb.NewUserServiceClient(conn)
+	c.health = healthpb.NewHealthClient(conn)
+	return c, nil
+}
+
+// clientTLSConfig builds the TLS configuration used by NewUserClientWithTLS
+func clientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tlsMinVersion}
+
+	if tlsInsecureSkipVerify {
+		logger.Warn("TLS_INSECURE_SKIP_VERIFY=on: server certificates are NOT verified, connections are open to interception. Use only for local testing.")
+		cfg.InsecureSkipVerify = true
+	}
+
+	if caFile != "" {
+		pemData, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no valid certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
 }
 
 func (c *UserClient) Close() error {
 	if c.conn != nil {
 		logger.Info("Closing gRPC client connection")
-		err := c.conn.Close()
+		var err error
+		if c.recycler != nil {
+			err = c.recycler.Close()
+		} else {
+			err = c.conn.Close()
+		}
 		if err != nil {
 			logger.WithError(err).Error("Error closing gRPC client connection")
 		} else {
@@ -74,6 +289,12 @@ func (c *UserClient) Close() error {
 }
 
 func (c *UserClient) CreateUser(name, email string, age int32) (*pb.User, error) {
+	if c.validate {
+		if err := validateUserFields(name, email, age); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
@@ -85,7 +306,7 @@ func (c *UserClient) CreateUser(name, email string, age int32) (*pb.User, error)
 
 	resp, err := c.client.CreateUser(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	if !resp.Success {
@@ -99,12 +320,47 @@ func (c *UserClient) CreateUser(name, email string, age int32) (*pb.User, error)
 	logger.WithFields(logrus.Fields{
 		"id":    resp.User.Id,
 		"name":  resp.User.Name,
-		"email": resp.User.Email,
+		"email": redact.Email(resp.User.Email),
 	}).Info("User created")
 	return resp.User, nil
 }
 
+// HealthCheck asks the server's standard gRPC health service whether the
+// UserService is ready, so callers can gate traffic on server readiness
+func (c *UserClient) HealthCheck(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	if c.health == nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, fmt.Errorf("health check: client is not connected")
+	}
+
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{Service: pb.UserService_ServiceDesc.ServiceName})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, fmt.Errorf("health check failed: %w", err)
+	}
+
+	logger.WithField("status", resp.Status.String()).Debug("Health check completed")
+	return resp.Status, nil
+}
+
 func (c *UserClient) GetUser(id int32) (*pb.User, error) {
+	resp, err := c.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
+
+// GetUserWithConfirmToken is GetUser that also returns the confirm token to
+// pass to DeleteUserConfirmed. The token is only valid while the user stays
+// as returned.
+func (c *UserClient) GetUserWithConfirmToken(id int32) (*pb.User, string, error) {
+	resp, err := c.getUser(id)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.User, resp.ConfirmToken, nil
+}
+
+func (c *UserClient) getUser(id int32) (*pb.GetUserResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
@@ -112,7 +368,7 @@ func (c *UserClient) GetUser(id int32) (*pb.User, error) {
 
 	resp, err := c.client.GetUser(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %v", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	if !resp.Success {
@@ -126,34 +382,188 @@ func (c *UserClient) GetUser(id int32) (*pb.User, error) {
 	logger.WithFields(logrus.Fields{
 		"id":    resp.User.Id,
 		"name":  resp.User.Name,
-		"email": resp.User.Email,
+		"email": redact.Email(resp.User.Email),
 	}).Info("User retrieved")
-	return resp.User, nil
+	return resp, nil
 }
 
 func (c *UserClient) ListUsers() ([]*pb.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	limit := c.pageSize
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
 	req := &pb.ListUsersRequest{
 		Page:  1,
-		Limit: 100,
+		Limit: limit,
 	}
 
-	resp, err := c.client.ListUsers(ctx, req)
+	var header metadata.MD
+	resp, err := c.client.ListUsers(ctx, req, grpc.Header(&header))
 	if err != nil {
-		return nil, fmt.Errorf("failed to list users: %v", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
 	if !resp.Success {
 		return nil, fmt.Errorf("failed to list users: %s", resp.Message)
 	}
 
-	logger.WithField("total", resp.Total).Info("Users listed")
+	fields := logrus.Fields{"total": resp.Total}
+	if v := header.Get(pageLimitHeader); len(v) > 0 {
+		fields["page_limit"] = v[0]
+	}
+	logger.WithFields(fields).Info("Users listed")
 	return resp.Users, nil
 }
 
+// IterateUsers pages through all users in id order, calling fn once per user.
+// It stops at the first error returned by fn or by the server. A pageSize of
+// zero uses the client's configured page size. Pages the server cut short to
+// respect its message size limit are continued through next_page_token.
+func (c *UserClient) IterateUsers(ctx context.Context, pageSize int32, fn func(*pb.User) error) error {
+	if pageSize <= 0 {
+		pageSize = c.pageSize
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	var token string
+	for page := int32(1); ; page++ {
+		var header metadata.MD
+		resp, err := c.client.ListUsers(ctx, &pb.ListUsersRequest{Page: page, Limit: pageSize, PageToken: token}, grpc.Header(&header))
+		if err != nil {
+			return fmt.Errorf("failed to list users (page %d): %w", page, err)
+		}
+		if !resp.Success {
+			return fmt.Errorf("failed to list users (page %d): %s", page, resp.Message)
+		}
+
+		for _, user := range resp.Users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+
+		if resp.NextPageToken != "" {
+			token = resp.NextPageToken
+			continue
+		}
+
+		// The server may clamp the page size; a page shorter than the
+		// applied limit is the last one
+		limit := pageSize
+		if v := header.Get(pageLimitHeader); len(v) > 0 {
+			if n, err := strconv.Atoi(v[0]); err == nil && n > 0 {
+				limit = int32(n)
+			}
+		}
+		if int32(len(resp.Users)) < limit {
+			return nil
+		}
+	}
+}
+
+// WatchUsers subscribes to user change events and calls fn for each one
+// until ctx is cancelled, the stream ends, or fn returns an error. Events
+// are delivered at most once; after reconnecting, callers should re-read
+// the users they care about.
+func (c *UserClient) WatchUsers(ctx context.Context, fn func(*pb.UserEvent) error) error {
+	stream, err := c.client.WatchUsers(ctx, &pb.WatchUsersRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to watch users: %w", err)
+	}
+
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to receive user event: %w", err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// ListUserIDs returns the ids of all users in id order, fetched page by page
+func (c *UserClient) ListUserIDs(ctx context.Context) ([]int32, error) {
+	limit := c.pageSize
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	var ids []int32
+	req := &pb.ListUserIDsRequest{Page: 1, Limit: limit}
+	for {
+		resp, err := c.client.ListUserIDs(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list user ids: %w", err)
+		}
+		ids = append(ids, resp.Ids...)
+		if resp.NextPageToken == "" {
+			return ids, nil
+		}
+		req = &pb.ListUserIDsRequest{Limit: limit, PageToken: resp.NextPageToken}
+	}
+}
+
+// ServerInfo returns the server's build information and active lock type
+func (c *UserClient) ServerInfo(ctx context.Context) (*pb.ServerInfoResponse, error) {
+	resp, err := c.client.ServerInfo(ctx, &pb.ServerInfoRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server info: %w", err)
+	}
+	return resp, nil
+}
+
+// UserStats returns user counts per age bucket and an age summary. bounds are
+// the lower ages of all but the first bucket; none uses the server's decades.
+func (c *UserClient) UserStats(ctx context.Context, bounds []int32) (*pb.UserStatsResponse, error) {
+	resp, err := c.client.UserStats(ctx, &pb.UserStatsRequest{AgeBounds: bounds})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	return resp, nil
+}
+
+// ExportUsersCSV streams the users table as CSV into w, header line first.
+// fields selects the columns like ListUsers fields; none exports all of them.
+func (c *UserClient) ExportUsersCSV(ctx context.Context, w io.Writer, fields []string) error {
+	stream, err := c.client.ExportUsersCSV(ctx, &pb.ExportUsersCSVRequest{Fields: fields})
+	if err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive users export: %w", err)
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
 func (c *UserClient) UpdateUser(id int32, name, email string, age int32) (*pb.User, error) {
+	if c.validate {
+		if err := validateUserFields(name, email, age); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
@@ -166,30 +576,64 @@ func (c *UserClient) UpdateUser(id int32, name, email string, age int32) (*pb.Us
 
 	resp, err := c.client.UpdateUser(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %v", err)
+		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
 	if !resp.Success {
 		return nil, fmt.Errorf("failed to update user: %s", resp.Message)
 	}
 
+	if resp.User == nil {
+		return nil, fmt.Errorf("server returned nil user despite success")
+	}
+
 	logger.WithFields(logrus.Fields{
 		"id":    resp.User.Id,
 		"name":  resp.User.Name,
-		"email": resp.User.Email,
+		"email": redact.Email(resp.User.Email),
 	}).Info("User updated")
 	return resp.User, nil
 }
 
+// UpdateUsers applies several updates in one transaction and returns the
+// per-record outcomes. Users that do not exist are reported as not updated
+// rather than as an error.
+func (c *UserClient) UpdateUsers(updates []*pb.UpdateUserRequest) (*pb.UpdateUsersResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	resp, err := c.client.UpdateUsers(ctx, &pb.UpdateUsersRequest{Users: updates})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update users: %w", err)
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("failed to update users: %s", resp.Message)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"updated":   resp.UpdatedCount,
+		"not_found": resp.NotFoundCount,
+	}).Info("Users updated")
+	return resp, nil
+}
+
 func (c *UserClient) DeleteUser(id int32) error {
+	return c.DeleteUserConfirmed(id, "")
+}
+
+// DeleteUserConfirmed deletes a user only if it is unchanged since the read
+// that issued confirmToken (see GetUserWithConfirmToken). Servers running
+// with REQUIRE_DELETE_CONFIRM=on refuse deletes without a token.
+func (c *UserClient) DeleteUserConfirmed(id int32, confirmToken string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	req := &pb.DeleteUserRequest{Id: id}
+	req := &pb.DeleteUserRequest{Id: id, ConfirmToken: confirmToken}
 
 	resp, err := c.client.DeleteUser(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %v", err)
+		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
 	if !resp.Success {
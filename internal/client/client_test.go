@@ -2,14 +2,33 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go-grpc-server-client/internal/testutil"
 	pb "go-grpc-server-client/proto"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
 )
 
 // MockUserServiceClient is a mock implementation of pb.UserServiceClient
@@ -57,6 +76,54 @@ func (m *MockUserServiceClient) DeleteUser(ctx context.Context, in *pb.DeleteUse
 	return args.Get(0).(*pb.DeleteUserResponse), args.Error(1)
 }
 
+func (m *MockUserServiceClient) UpdateUsers(ctx context.Context, in *pb.UpdateUsersRequest, opts ...grpc.CallOption) (*pb.UpdateUsersResponse, error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.UpdateUsersResponse), args.Error(1)
+}
+
+func (m *MockUserServiceClient) WatchUsers(ctx context.Context, in *pb.WatchUsersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.UserEvent], error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(grpc.ServerStreamingClient[pb.UserEvent]), args.Error(1)
+}
+
+func (m *MockUserServiceClient) ListUserIDs(ctx context.Context, in *pb.ListUserIDsRequest, opts ...grpc.CallOption) (*pb.ListUserIDsResponse, error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.ListUserIDsResponse), args.Error(1)
+}
+
+func (m *MockUserServiceClient) ServerInfo(ctx context.Context, in *pb.ServerInfoRequest, opts ...grpc.CallOption) (*pb.ServerInfoResponse, error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.ServerInfoResponse), args.Error(1)
+}
+
+func (m *MockUserServiceClient) UserStats(ctx context.Context, in *pb.UserStatsRequest, opts ...grpc.CallOption) (*pb.UserStatsResponse, error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.UserStatsResponse), args.Error(1)
+}
+
+func (m *MockUserServiceClient) ExportUsersCSV(ctx context.Context, in *pb.ExportUsersCSVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.CSVChunk], error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(grpc.ServerStreamingClient[pb.CSVChunk]), args.Error(1)
+}
+
 func TestUserClient_CreateUser(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -307,6 +374,24 @@ func TestUserClient_GetUser(t *testing.T) {
 	}
 }
 
+func TestUserClient_GetUser_PreservesStatus(t *testing.T) {
+	for _, code := range []codes.Code{codes.NotFound, codes.Unavailable, codes.PermissionDenied} {
+		t.Run(code.String(), func(t *testing.T) {
+			mockClient := &MockUserServiceClient{}
+			mockClient.On("GetUser", mock.Anything, &pb.GetUserRequest{Id: 1}, mock.Anything).Return(nil, status.Error(code, "rpc failed"))
+			client := &UserClient{client: mockClient}
+
+			_, err := client.GetUser(1)
+
+			require.Error(t, err)
+			assert.Equal(t, code, status.Code(err))
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, code, st.Code())
+		})
+	}
+}
+
 func TestUserClient_GetUser_ErrorCases(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -507,6 +592,23 @@ func TestUserClient_UpdateUser(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name:     "server returns nil user",
+			userID:   1,
+			userName: "John Updated",
+			email:    "john.updated@example.com",
+			age:      31,
+			setup: func(mockClient *MockUserServiceClient) {
+				response := &pb.UpdateUserResponse{
+					Success: true,
+					Message: "User updated successfully",
+					User:    nil,
+				}
+				mockClient.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything).Return(response, nil)
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -596,6 +698,25 @@ func TestUserClient_DeleteUser(t *testing.T) {
 	}
 }
 
+func TestUserClient_DeleteUserConfirmed(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	client := &UserClient{client: mockClient}
+
+	mockClient.On("GetUser", mock.Anything, &pb.GetUserRequest{Id: 1}, mock.Anything).Return(&pb.GetUserResponse{
+		User:         &pb.User{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30},
+		Success:      true,
+		ConfirmToken: "0123456789abcdef",
+	}, nil)
+	mockClient.On("DeleteUser", mock.Anything, &pb.DeleteUserRequest{Id: 1, ConfirmToken: "0123456789abcdef"}, mock.Anything).
+		Return(&pb.DeleteUserResponse{Success: true}, nil)
+
+	user, token, err := client.GetUserWithConfirmToken(1)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", user.Name)
+	require.NoError(t, client.DeleteUserConfirmed(user.Id, token))
+	mockClient.AssertExpectations(t)
+}
+
 func TestUserClient_Close(t *testing.T) {
 	// Create a client with a mock connection
 	mockClient := &MockUserServiceClient{}
@@ -608,3 +729,522 @@ func TestUserClient_Close(t *testing.T) {
 	err := client.Close()
 	assert.NoError(t, err)
 }
+
+func TestUserClient_ListUsers_PageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantLimit int32
+	}{
+		{name: "default page size", wantLimit: defaultPageSize},
+		{name: "configured page size", opts: []Option{WithPageSize(25)}, wantLimit: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockUserServiceClient{}
+			response := &pb.ListUsersResponse{Success: true, Message: "Users retrieved successfully"}
+			mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 1, Limit: tt.wantLimit}, mock.Anything).Return(response, nil)
+
+			client := &UserClient{client: mockClient}
+			for _, opt := range tt.opts {
+				opt(client)
+			}
+
+			_, err := client.ListUsers()
+			assert.NoError(t, err)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserClient_IterateUsers(t *testing.T) {
+	page := func(ids ...int32) *pb.ListUsersResponse {
+		resp := &pb.ListUsersResponse{Success: true}
+		for _, id := range ids {
+			resp.Users = append(resp.Users, &pb.User{Id: id, Name: fmt.Sprintf("user-%d", id)})
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name    string
+		pages   []*pb.ListUsersResponse
+		wantIDs []int32
+	}{
+		{name: "short last page", pages: []*pb.ListUsersResponse{page(1, 2), page(3)}, wantIDs: []int32{1, 2, 3}},
+		{name: "full last page", pages: []*pb.ListUsersResponse{page(1, 2), page(3, 4), page()}, wantIDs: []int32{1, 2, 3, 4}},
+		{name: "no users", pages: []*pb.ListUsersResponse{page()}, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockUserServiceClient{}
+			for i, resp := range tt.pages {
+				mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: int32(i + 1), Limit: 2}, mock.Anything).Return(resp, nil).Once()
+			}
+			client := &UserClient{client: mockClient}
+
+			var seen []int32
+			err := client.IterateUsers(context.Background(), 2, func(u *pb.User) error {
+				seen = append(seen, u.Id)
+				return nil
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantIDs, seen)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserClient_IterateUsers_FollowsPageToken(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	// The first page was cut short by the server's message size limit
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 1, Limit: 3}, mock.Anything).Return(&pb.ListUsersResponse{
+		Success:       true,
+		Users:         []*pb.User{{Id: 1}},
+		NextPageToken: "after-1",
+	}, nil).Once()
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 2, Limit: 3, PageToken: "after-1"}, mock.Anything).Return(&pb.ListUsersResponse{
+		Success: true,
+		Users:   []*pb.User{{Id: 2}, {Id: 3}},
+	}, nil).Once()
+	client := &UserClient{client: mockClient}
+
+	var seen []int32
+	err := client.IterateUsers(context.Background(), 3, func(u *pb.User) error {
+		seen = append(seen, u.Id)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2, 3}, seen)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_ListUserIDs(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("ListUserIDs", mock.Anything, &pb.ListUserIDsRequest{Page: 1, Limit: 2}, mock.Anything).Return(&pb.ListUserIDsResponse{
+		Ids:           []int32{1, 4},
+		NextPageToken: "after-4",
+	}, nil).Once()
+	mockClient.On("ListUserIDs", mock.Anything, &pb.ListUserIDsRequest{Limit: 2, PageToken: "after-4"}, mock.Anything).Return(&pb.ListUserIDsResponse{
+		Ids: []int32{6},
+	}, nil).Once()
+	client := &UserClient{client: mockClient, pageSize: 2}
+
+	ids, err := client.ListUserIDs(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 4, 6}, ids)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_UserStats(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	want := &pb.UserStatsResponse{
+		Buckets: []*pb.AgeBucket{{MinAge: 0, MaxAge: 29, Count: 3}, {MinAge: 30, MaxAge: 150, Count: 1}},
+		Total:   4,
+		MinAge:  21,
+		MaxAge:  44,
+		AvgAge:  28.25,
+	}
+	mockClient.On("UserStats", mock.Anything, &pb.UserStatsRequest{AgeBounds: []int32{30}}, mock.Anything).Return(want, nil)
+	client := &UserClient{client: mockClient}
+
+	got, err := client.UserStats(context.Background(), []int32{30})
+
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_ServerInfo(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	want := &pb.ServerInfoResponse{Version: "v1.2.3", BuildTime: "2024-01-01T00:00:00Z", GitCommit: "abc1234", LockType: "redis"}
+	mockClient.On("ServerInfo", mock.Anything, &pb.ServerInfoRequest{}, mock.Anything).Return(want, nil)
+	client := &UserClient{client: mockClient}
+
+	got, err := client.ServerInfo(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_IterateUsers_StopsOnCallbackError(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 1, Limit: 2}, mock.Anything).Return(&pb.ListUsersResponse{
+		Success: true,
+		Users:   []*pb.User{{Id: 1}, {Id: 2}},
+	}, nil).Once()
+	client := &UserClient{client: mockClient}
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err := client.IterateUsers(context.Background(), 2, func(u *pb.User) error {
+		calls++
+		return stop
+	})
+
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_IterateUsers_ServerError(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("ListUsers", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("server error"))
+	client := &UserClient{client: mockClient}
+
+	err := client.IterateUsers(context.Background(), 2, func(u *pb.User) error { return nil })
+
+	assert.Error(t, err)
+}
+
+// stubUserServer answers GetUser with a fixed user and ListUsers with
+// req.Limit generated users
+type stubUserServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (stubUserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	return &pb.GetUserResponse{
+		User:    &pb.User{Id: req.Id, Name: "John Doe", Email: "john@example.com", Age: 30},
+		Success: true,
+		Message: "User found successfully",
+	}, nil
+}
+
+func (stubUserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	users := make([]*pb.User, req.Limit)
+	for i := range users {
+		users[i] = &pb.User{
+			Id:        int32(i + 1),
+			Name:      "John Doe",
+			Email:     fmt.Sprintf("john%d@example.com", i+1),
+			Age:       30,
+			CreatedAt: "2023-01-01T00:00:00Z",
+			UpdatedAt: "2023-01-01T00:00:00Z",
+		}
+	}
+	return &pb.ListUsersResponse{Users: users, Total: req.Limit, Success: true}, nil
+}
+
+func TestNewUserClientWithTLS_MutualTLS(t *testing.T) {
+	certs := testutil.WriteTestCerts(t)
+
+	serverCert, err := tls.LoadX509KeyPair(certs.ServerCertFile, certs.ServerKeyFile)
+	require.NoError(t, err)
+	caPEM, err := os.ReadFile(certs.CAFile)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(caPEM))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	t.Run("with client certificate", func(t *testing.T) {
+		c, err := NewUserClientWithTLS(lis.Addr().String(), certs.CAFile, certs.ClientCertFile, certs.ClientKeyFile)
+		require.NoError(t, err)
+		defer c.Close()
+
+		user, err := c.GetUser(7)
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), user.Id)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		c, err := NewUserClientWithTLS(lis.Addr().String(), certs.CAFile, "", "")
+		require.NoError(t, err)
+		defer c.Close()
+
+		_, err = c.GetUser(7)
+		assert.Error(t, err)
+	})
+}
+
+func TestClientTLSConfig_MinVersion(t *testing.T) {
+	cfg, err := clientTLSConfig("", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+
+	// A server capped at TLS 1.1 is refused
+	certs := testutil.WriteTestCerts(t)
+	serverCert, err := tls.LoadX509KeyPair(certs.ServerCertFile, certs.ServerKeyFile)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS11,
+	})))
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClientWithTLS(lis.Addr().String(), certs.CAFile, "", "")
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.GetUser(7)
+	assert.Error(t, err)
+}
+
+func TestNewUserClient_WrapsErrors(t *testing.T) {
+	_, err := NewUserClientWithTLS("localhost:50051", "/nonexistent/ca.pem", "", "")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = NewUserClient("dns:///[::1")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "failed to connect")
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestNewUserClientWithTLS_InsecureSkipVerify(t *testing.T) {
+	defer func(v bool) { tlsInsecureSkipVerify = v }(tlsInsecureSkipVerify)
+
+	// Without the CA file the test server's certificate is untrusted, as a
+	// self-signed one would be
+	certs := testutil.WriteTestCerts(t)
+	serverCert, err := tls.LoadX509KeyPair(certs.ServerCertFile, certs.ServerKeyFile)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	for _, tt := range []struct {
+		name       string
+		skipVerify bool
+		wantErr    bool
+	}{
+		{name: "verification on", skipVerify: false, wantErr: true},
+		{name: "skip verify", skipVerify: true, wantErr: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsInsecureSkipVerify = tt.skipVerify
+
+			c, err := NewUserClientWithTLS(lis.Addr().String(), "", "", "")
+			require.NoError(t, err)
+			defer c.Close()
+
+			_, err = c.GetUser(7)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserClient_UpdateUsers(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	client := &UserClient{client: mockClient}
+
+	updates := []*pb.UpdateUserRequest{
+		{Id: 1, Name: "Alice", Email: "alice@example.com", Age: 31},
+		{Id: 999, Name: "Ghost", Email: "ghost@example.com", Age: 40},
+	}
+	mockClient.On("UpdateUsers", mock.Anything, &pb.UpdateUsersRequest{Users: updates}, mock.Anything).Return(&pb.UpdateUsersResponse{
+		Results: []*pb.UpdateUserResult{
+			{Id: 1, Updated: true, User: &pb.User{Id: 1, Name: "Alice", Email: "alice@example.com", Age: 31}},
+			{Id: 999},
+		},
+		UpdatedCount:  1,
+		NotFoundCount: 1,
+		Success:       true,
+	}, nil)
+
+	got, err := client.UpdateUsers(updates)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), got.UpdatedCount)
+	assert.Equal(t, int32(1), got.NotFoundCount)
+	assert.True(t, got.Results[0].Updated)
+	assert.False(t, got.Results[1].Updated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_HealthCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClient(lis.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+
+	t.Run("service not registered", func(t *testing.T) {
+		got, err := c.HealthCheck(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, got)
+	})
+
+	service := pb.UserService_ServiceDesc.ServiceName
+	tests := []struct {
+		name   string
+		status healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "serving", status: healthpb.HealthCheckResponse_SERVING},
+		{name: "not serving", status: healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthServer.SetServingStatus(service, tt.status)
+
+			got, err := c.HealthCheck(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, got)
+		})
+	}
+}
+
+// payloadRecorder is a server stats handler recording the sizes of sent messages
+type payloadRecorder struct {
+	mu       sync.Mutex
+	payloads []*stats.OutPayload
+}
+
+func (r *payloadRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *payloadRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *payloadRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func (r *payloadRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if out, ok := s.(*stats.OutPayload); ok {
+		r.mu.Lock()
+		r.payloads = append(r.payloads, out)
+		r.mu.Unlock()
+	}
+}
+
+func TestUserClient_ListUsers_Gzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		gzip           bool
+		wantCompressed bool
+	}{
+		{name: "compression off", gzip: false, wantCompressed: false},
+		{name: "GRPC_COMPRESSION=gzip", gzip: true, wantCompressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &payloadRecorder{}
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			s := grpc.NewServer(grpc.StatsHandler(recorder))
+			pb.RegisterUserServiceServer(s, stubUserServer{})
+			go s.Serve(lis)
+			defer s.Stop()
+
+			defer func(prev bool) { useGzip = prev }(useGzip)
+			useGzip = tt.gzip
+
+			c, err := NewUserClient(lis.Addr().String(), WithPageSize(1000))
+			require.NoError(t, err)
+			defer c.Close()
+
+			users, err := c.ListUsers()
+			require.NoError(t, err)
+			assert.Len(t, users, 1000)
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			require.Len(t, recorder.payloads, 1)
+			out := recorder.payloads[0]
+			if tt.wantCompressed {
+				assert.Less(t, out.CompressedLength, out.Length/2, "response was not compressed")
+			} else {
+				assert.Equal(t, out.Length, out.CompressedLength)
+			}
+		})
+	}
+}
+
+func TestNewUserClient_IdleTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	defer func(prev time.Duration) { idleTimeout = prev }(idleTimeout)
+	idleTimeout = 100 * time.Millisecond
+
+	c, err := NewUserClient(lis.Addr().String(), WithPageSize(10))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.ListUsers()
+	require.NoError(t, err)
+
+	// The idle connection is torn down...
+	assert.Eventually(t, func() bool { return c.conn.GetState() == connectivity.Idle }, 2*time.Second, 20*time.Millisecond)
+
+	// ...and re-established by the next call
+	users, err := c.ListUsers()
+	require.NoError(t, err)
+	assert.NotEmpty(t, users)
+	assert.Equal(t, connectivity.Ready, c.conn.GetState())
+}
+
+func TestNewUserClient_UserAgent(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		userAgent []string
+	)
+	recordUserAgent := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		mu.Lock()
+		userAgent = md.Get("user-agent")
+		mu.Unlock()
+		return handler(ctx, req)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.UnaryInterceptor(recordUserAgent))
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClient(lis.Addr().String(), WithUserAgent("user-admin", "1.4.2"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.GetUser(1)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, userAgent, 1)
+	assert.True(t, strings.HasPrefix(userAgent[0], "user-admin/1.4.2 grpc-go/"), userAgent[0])
+}
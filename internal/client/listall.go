@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "go-grpc-server-client/proto"
+)
+
+// defaultMaxListAllUsers caps the users ListAllUsers holds in memory
+const defaultMaxListAllUsers = 100000
+
+// ErrTooManyUsers is returned by ListAllUsers when there are more users than
+// its cap allows
+var ErrTooManyUsers = errors.New("too many users to list at once")
+
+// WithMaxListAllUsers sets how many users ListAllUsers assembles before it
+// gives up with ErrTooManyUsers
+func WithMaxListAllUsers(n int) Option {
+	return func(c *UserClient) {
+		c.maxListAllUsers = n
+	}
+}
+
+// ListAllUsers pages through ListUsers until the table is exhausted and
+// returns every user in id order. Callers that can process users one at a
+// time should prefer IterateUsers, which does not hold them all in memory.
+func (c *UserClient) ListAllUsers(ctx context.Context) ([]*pb.User, error) {
+	limit := c.maxListAllUsers
+	if limit <= 0 {
+		limit = defaultMaxListAllUsers
+	}
+
+	var users []*pb.User
+	err := c.IterateUsers(ctx, 0, func(user *pb.User) error {
+		if len(users) >= limit {
+			return fmt.Errorf("%w: more than %d users", ErrTooManyUsers, limit)
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserClient_ListAllUsers(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 1, Limit: 2}, mock.Anything).
+		Return(&pb.ListUsersResponse{Success: true, Users: []*pb.User{{Id: 1}, {Id: 2}}}, nil).Once()
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 2, Limit: 2}, mock.Anything).
+		Return(&pb.ListUsersResponse{Success: true, Users: []*pb.User{{Id: 3}, {Id: 4}}}, nil).Once()
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 3, Limit: 2}, mock.Anything).
+		Return(&pb.ListUsersResponse{Success: true, Users: []*pb.User{{Id: 5}}}, nil).Once()
+	client := &UserClient{client: mockClient, pageSize: 2}
+
+	users, err := client.ListAllUsers(context.Background())
+
+	require.NoError(t, err)
+	var ids []int32
+	for _, u := range users {
+		ids = append(ids, u.Id)
+	}
+	assert.Equal(t, []int32{1, 2, 3, 4, 5}, ids)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUserClient_ListAllUsers_Cap(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 1, Limit: 2}, mock.Anything).
+		Return(&pb.ListUsersResponse{Success: true, Users: []*pb.User{{Id: 1}, {Id: 2}}}, nil).Once()
+	mockClient.On("ListUsers", mock.Anything, &pb.ListUsersRequest{Page: 2, Limit: 2}, mock.Anything).
+		Return(&pb.ListUsersResponse{Success: true, Users: []*pb.User{{Id: 3}, {Id: 4}}}, nil).Once()
+	client := &UserClient{client: mockClient, pageSize: 2}
+	WithMaxListAllUsers(3)(client)
+
+	users, err := client.ListAllUsers(context.Background())
+
+	assert.ErrorIs(t, err, ErrTooManyUsers)
+	assert.Nil(t, users)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// UserClientPool maintains several connections to the same server and
+// spreads RPCs across them in round-robin order, so that high-concurrency
+// callers are not limited by the stream cap of a single HTTP/2 connection.
+// It exposes the same method surface as UserClient and is safe for
+// concurrent use.
+type UserClientPool struct {
+	clients []*UserClient
+	next    uint64
+}
+
+func NewUserClientPool(serverAddr string, size int, opts ...Option) (*UserClientPool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid pool size %d: must be positive", size)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"server_addr": serverAddr,
+		"pool_size":   size,
+	}).Info("Creating gRPC client pool")
+
+	pool := &UserClientPool{clients: make([]*UserClient, 0, size)}
+	for i := 0; i < size; i++ {
+		c, err := NewUserClient(serverAddr, opts...)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.clients = append(pool.clients, c)
+	}
+
+	return pool, nil
+}
+
+// pick returns the next client in round-robin order
+func (p *UserClientPool) pick() *UserClient {
+	n := atomic.AddUint64(&p.next, 1)
+	return p.clients[(n-1)%uint64(len(p.clients))]
+}
+
+// Size returns the number of underlying connections
+func (p *UserClientPool) Size() int {
+	return len(p.clients)
+}
+
+// Close closes every underlying connection and returns the first error encountered
+func (p *UserClientPool) Close() error {
+	var firstErr error
+	for _, c := range p.clients {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (p *UserClientPool) HealthCheck(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	return p.pick().HealthCheck(ctx)
+}
+
+func (p *UserClientPool) CreateUser(name, email string, age int32) (*pb.User, error) {
+	return p.pick().CreateUser(name, email, age)
+}
+
+func (p *UserClientPool) GetUser(id int32) (*pb.User, error) {
+	return p.pick().GetUser(id)
+}
+
+func (p *UserClientPool) GetUserWithConfirmToken(id int32) (*pb.User, string, error) {
+	return p.pick().GetUserWithConfirmToken(id)
+}
+
+func (p *UserClientPool) GetUsersBatch(ids []int32) (map[int32]*pb.User, map[int32]error) {
+	return p.pick().GetUsersBatch(ids)
+}
+
+func (p *UserClientPool) ListUsers() ([]*pb.User, error) {
+	return p.pick().ListUsers()
+}
+
+func (p *UserClientPool) IterateUsers(ctx context.Context, pageSize int32, fn func(*pb.User) error) error {
+	return p.pick().IterateUsers(ctx, pageSize, fn)
+}
+
+func (p *UserClientPool) ListAllUsers(ctx context.Context) ([]*pb.User, error) {
+	return p.pick().ListAllUsers(ctx)
+}
+
+func (p *UserClientPool) WatchUsers(ctx context.Context, fn func(*pb.UserEvent) error) error {
+	return p.pick().WatchUsers(ctx, fn)
+}
+
+func (p *UserClientPool) ListUserIDs(ctx context.Context) ([]int32, error) {
+	return p.pick().ListUserIDs(ctx)
+}
+
+func (p *UserClientPool) ServerInfo(ctx context.Context) (*pb.ServerInfoResponse, error) {
+	return p.pick().ServerInfo(ctx)
+}
+
+func (p *UserClientPool) UserStats(ctx context.Context, bounds []int32) (*pb.UserStatsResponse, error) {
+	return p.pick().UserStats(ctx, bounds)
+}
+
+func (p *UserClientPool) ExportUsersCSV(ctx context.Context, w io.Writer, fields []string) error {
+	return p.pick().ExportUsersCSV(ctx, w, fields)
+}
+
+func (p *UserClientPool) UpdateUser(id int32, name, email string, age int32) (*pb.User, error) {
+	return p.pick().UpdateUser(id, name, email, age)
+}
+
+func (p *UserClientPool) UpdateUsers(updates []*pb.UpdateUserRequest) (*pb.UpdateUsersResponse, error) {
+	return p.pick().UpdateUsers(updates)
+}
+
+func (p *UserClientPool) DeleteUser(id int32) error {
+	return p.pick().DeleteUser(id)
+}
+
+func (p *UserClientPool) DeleteUserConfirmed(id int32, confirmToken string) error {
+	return p.pick().DeleteUserConfirmed(id, confirmToken)
+}
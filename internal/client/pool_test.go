@@ -0,0 +1,51 @@
+package client
+
+import (
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserClientPool_RoundRobin(t *testing.T) {
+	const size = 3
+	const rounds = 4
+
+	mocks := make([]*MockUserServiceClient, size)
+	pool := &UserClientPool{}
+	for i := range mocks {
+		mocks[i] = &MockUserServiceClient{}
+		response := &pb.GetUserResponse{
+			User:    &pb.User{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30},
+			Success: true,
+			Message: "User found successfully",
+		}
+		mocks[i].On("GetUser", mock.Anything, mock.Anything, mock.Anything).Return(response, nil)
+		pool.clients = append(pool.clients, &UserClient{client: mocks[i]})
+	}
+
+	for i := 0; i < size*rounds; i++ {
+		_, err := pool.GetUser(1)
+		assert.NoError(t, err)
+	}
+
+	// Every underlying connection should have served the same share of requests
+	for _, m := range mocks {
+		m.AssertNumberOfCalls(t, "GetUser", rounds)
+	}
+}
+
+func TestNewUserClientPool_InvalidSize(t *testing.T) {
+	pool, err := NewUserClientPool("localhost:50051", 0)
+	assert.Error(t, err)
+	assert.Nil(t, pool)
+}
+
+func TestNewUserClientPool(t *testing.T) {
+	pool, err := NewUserClientPool("localhost:50051", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pool.Size())
+	assert.NoError(t, pool.Close())
+}
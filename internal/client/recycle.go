@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// WithMaxRequestsPerConn recycles the connection after n RPCs: the next RPC
+// dials a new connection and the old one is closed once its in-flight RPCs
+// finish. Useful behind proxies that throttle long-lived connections. Zero
+// keeps one connection for the client's lifetime.
+func WithMaxRequestsPerConn(n int) Option {
+	return func(c *UserClient) {
+		c.maxRequestsPerConn = n
+	}
+}
+
+// recyclingConn is a grpc.ClientConnInterface that replaces its underlying
+// connection every maxRequests RPCs
+type recyclingConn struct {
+	dial        func() (*grpc.ClientConn, error)
+	maxRequests int
+
+	mu      sync.Mutex
+	cur     *countedConn
+	retired []*countedConn
+}
+
+// countedConn is a connection with its request and in-flight RPC counts
+type countedConn struct {
+	*grpc.ClientConn
+	requests int
+	inflight int
+}
+
+func newRecyclingConn(conn *grpc.ClientConn, maxRequests int, dial func() (*grpc.ClientConn, error)) *recyclingConn {
+	return &recyclingConn{dial: dial, maxRequests: maxRequests, cur: &countedConn{ClientConn: conn}}
+}
+
+// acquire returns the connection for the next RPC, recycling the current one
+// once it has served maxRequests. If dialing fails the current connection
+// keeps serving and the next RPC tries again.
+func (r *recyclingConn) acquire() *countedConn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cur.requests >= r.maxRequests {
+		conn, err := r.dial()
+		if err != nil {
+			logger.WithError(err).Warn("Failed to dial replacement connection, keeping the current one")
+		} else {
+			logger.WithFields(logrus.Fields{
+				"requests": r.cur.requests,
+				"inflight": r.cur.inflight,
+			}).Debug("Recycling gRPC connection")
+			r.retire(r.cur)
+			r.cur = &countedConn{ClientConn: conn}
+		}
+	}
+	r.cur.requests++
+	r.cur.inflight++
+	return r.cur
+}
+
+// retire closes cc now if it is idle, or when its last RPC finishes.
+// r.mu must be held.
+func (r *recyclingConn) retire(cc *countedConn) {
+	if cc.inflight == 0 {
+		cc.Close()
+		return
+	}
+	r.retired = append(r.retired, cc)
+}
+
+// release marks an RPC on cc as finished
+func (r *recyclingConn) release(cc *countedConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cc.inflight--
+	if cc == r.cur || cc.inflight > 0 {
+		return
+	}
+	for i, old := range r.retired {
+		if old == cc {
+			r.retired = append(r.retired[:i], r.retired[i+1:]...)
+			break
+		}
+	}
+	cc.Close()
+}
+
+func (r *recyclingConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	cc := r.acquire()
+	defer r.release(cc)
+	return cc.Invoke(ctx, method, args, reply, opts...)
+}
+
+func (r *recyclingConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cc := r.acquire()
+	stream, err := cc.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		r.release(cc)
+		return nil, err
+	}
+	// gRPC cancels a stream's context once the stream has finished
+	go func() {
+		<-stream.Context().Done()
+		r.release(cc)
+	}()
+	return stream, nil
+}
+
+// Close closes the current connection and any retired ones still draining
+func (r *recyclingConn) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cc := range r.retired {
+		cc.Close()
+	}
+	r.retired = nil
+	return r.cur.Close()
+}
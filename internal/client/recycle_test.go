@@ -0,0 +1,99 @@
+package client
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// countingListener counts accepted and currently open connections
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+	open     atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.accepted.Add(1)
+	l.open.Add(1)
+	return &countedNetConn{Conn: conn, open: &l.open}, nil
+}
+
+type countedNetConn struct {
+	net.Conn
+	open   *atomic.Int32
+	closed atomic.Bool
+}
+
+func (c *countedNetConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil && c.closed.CompareAndSwap(false, true) {
+		c.open.Add(-1)
+	}
+	return n, err
+}
+
+func TestUserClient_MaxRequestsPerConn(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	lis := &countingListener{Listener: inner}
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClient(lis.Addr().String(), WithMaxRequestsPerConn(3))
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 1; i <= 3; i++ {
+		_, err := c.GetUser(int32(i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), lis.accepted.Load())
+
+	// The 4th request goes over a new connection
+	_, err = c.GetUser(4)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), lis.accepted.Load())
+
+	for i := 5; i <= 7; i++ {
+		_, err := c.GetUser(int32(i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), lis.accepted.Load())
+
+	// Recycled connections are closed, only the current one stays open
+	assert.Eventually(t, func() bool { return lis.open.Load() == 1 }, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestUserClient_WithoutMaxRequestsPerConn(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	lis := &countingListener{Listener: inner}
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, stubUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	c, err := NewUserClient(lis.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 1; i <= 10; i++ {
+		_, err := c.GetUser(int32(i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), lis.accepted.Load())
+}
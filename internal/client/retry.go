@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Retry budget defaults: retries may add at most defaultRetryBudgetRatio of
+// the requests seen in the last retryBudgetWindow, plus a small reserve so
+// that low-traffic clients can still retry at all
+const (
+	defaultRetryBudgetRatio    = 0.1
+	retryBudgetReserve         = 10
+	retryBudgetWindow          = 10 * time.Second
+	retryBudgetBucketCount     = 10
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+)
+
+// retryBackoff is the delay before the first retry; it doubles per attempt
+var retryBackoff = defaultRetryInitialBackoff
+
+type budgetBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+// retryBudget caps retries to a fraction of recent requests, as recommended
+// by the gRPC retry design (A6), so that an outage does not turn into a retry
+// storm. Requests deposit into a sliding window of time buckets and a retry is
+// only allowed while retries in the window stay below
+// reserve + ratio*requests.
+type retryBudget struct {
+	mu      sync.Mutex
+	ratio   float64
+	reserve int
+	width   time.Duration
+	buckets [retryBudgetBucketCount]budgetBucket
+	now     func() time.Time
+}
+
+func newRetryBudget(ratio float64, reserve int, window time.Duration) *retryBudget {
+	return &retryBudget{
+		ratio:   ratio,
+		reserve: reserve,
+		width:   window / retryBudgetBucketCount,
+		now:     time.Now,
+	}
+}
+
+// current returns the bucket for now, recycling it if it belongs to an
+// earlier window. Callers must hold b.mu.
+func (b *retryBudget) current(now time.Time) *budgetBucket {
+	start := now.Truncate(b.width)
+	bucket := &b.buckets[(start.UnixNano()/int64(b.width))%retryBudgetBucketCount]
+	if !bucket.start.Equal(start) {
+		*bucket = budgetBucket{start: start}
+	}
+	return bucket
+}
+
+// totals sums the buckets inside the sliding window. Callers must hold b.mu.
+func (b *retryBudget) totals(now time.Time) (requests, retries int) {
+	oldest := now.Truncate(b.width).Add(-b.width * (retryBudgetBucketCount - 1))
+	for _, bucket := range b.buckets {
+		if !bucket.start.Before(oldest) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
+
+// recordRequest counts an original (non-retry) request
+func (b *retryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current(b.now()).requests++
+}
+
+// tryRetry withdraws a retry from the budget, returning false when it is spent
+func (b *retryBudget) tryRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	requests, retries := b.totals(now)
+	if retries >= b.reserve+int(b.ratio*float64(requests)) {
+		return false
+	}
+	b.current(now).retries++
+	return true
+}
+
+// retryInterceptor retries calls failing with codes.Unavailable up to
+// maxAttempts times in total, with exponential backoff, as long as the budget
+// allows it
+func retryInterceptor(maxAttempts int, budget *retryBudget) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		budget.recordRequest()
+
+		backoff := retryBackoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= maxAttempts || status.Code(err) != codes.Unavailable {
+				return err
+			}
+			if !budget.tryRetry() {
+				logger.WithError(err).WithField("method", method).Warn("Retry budget exhausted, not retrying")
+				return err
+			}
+
+			logger.WithError(err).WithFields(logrus.Fields{
+				"method":  method,
+				"attempt": attempt + 1,
+			}).Debug("Retrying request")
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
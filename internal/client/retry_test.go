@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryBudget_CapsRetriesToRatio(t *testing.T) {
+	budget := newRetryBudget(0.1, 0, 10*time.Second)
+
+	retries := 0
+	for i := 0; i < 1000; i++ {
+		budget.recordRequest()
+		// Every request fails and wants to retry
+		if budget.tryRetry() {
+			retries++
+		}
+	}
+
+	assert.LessOrEqual(t, retries, 100)
+	assert.Greater(t, retries, 0)
+}
+
+func TestRetryBudget_ReserveAllowsRetriesAtLowTraffic(t *testing.T) {
+	budget := newRetryBudget(0.1, 3, 10*time.Second)
+	budget.recordRequest()
+
+	assert.True(t, budget.tryRetry())
+	assert.True(t, budget.tryRetry())
+	assert.True(t, budget.tryRetry())
+	assert.False(t, budget.tryRetry())
+}
+
+func TestRetryBudget_WindowSlides(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	budget := newRetryBudget(0.5, 0, 10*time.Second)
+	budget.now = func() time.Time { return now }
+
+	budget.recordRequest()
+	budget.recordRequest()
+	assert.True(t, budget.tryRetry())
+	assert.False(t, budget.tryRetry())
+
+	// Once the window has passed, old retries no longer count against the budget
+	now = now.Add(11 * time.Second)
+	budget.recordRequest()
+	budget.recordRequest()
+	assert.True(t, budget.tryRetry())
+}
+
+func TestRetryInterceptor_SustainedFailures(t *testing.T) {
+	defer func(prev time.Duration) { retryBackoff = prev }(retryBackoff)
+	retryBackoff = 0
+
+	const (
+		requests    = 500
+		ratio       = 0.2
+		reserve     = 5
+		maxAttempts = 3
+	)
+	interceptor := retryInterceptor(maxAttempts, newRetryBudget(ratio, reserve, time.Minute))
+
+	attempts := 0
+	unavailable := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.Unavailable, "server down")
+	}
+
+	for i := 0; i < requests; i++ {
+		err := interceptor(context.Background(), "/service.UserService/GetUser", nil, nil, nil, unavailable)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	retries := attempts - requests
+	assert.Greater(t, retries, 0)
+	assert.LessOrEqual(t, retries, reserve+int(ratio*requests))
+}
+
+func TestRetryInterceptor_RetriesOnlyUnavailable(t *testing.T) {
+	defer func(prev time.Duration) { retryBackoff = prev }(retryBackoff)
+	retryBackoff = 0
+
+	interceptor := retryInterceptor(3, newRetryBudget(0.1, 10, time.Minute))
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantCode     codes.Code
+	}{
+		{name: "success", errs: []error{nil}, wantAttempts: 1, wantCode: codes.OK},
+		{name: "recovers after unavailable", errs: []error{status.Error(codes.Unavailable, "down"), nil}, wantAttempts: 2, wantCode: codes.OK},
+		{name: "gives up after max attempts", errs: []error{status.Error(codes.Unavailable, "down")}, wantAttempts: 3, wantCode: codes.Unavailable},
+		{name: "not retryable", errs: []error{status.Error(codes.InvalidArgument, "bad")}, wantAttempts: 1, wantCode: codes.InvalidArgument},
+		{name: "plain error", errs: []error{fmt.Errorf("boom")}, wantAttempts: 1, wantCode: codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				err := tt.errs[min(attempts, len(tt.errs)-1)]
+				attempts++
+				return err
+			}
+
+			err := interceptor(context.Background(), "/service.UserService/GetUser", nil, nil, nil, invoker)
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits mirrored from the server's defaults. The server may be configured
+// stricter (MAX_NAME_LEN, ALLOWED_EMAIL_DOMAINS) and remains the authority.
+const (
+	maxNameLength  = 255
+	maxEmailLength = 255
+	minAge         = 0
+	maxAge         = 150
+)
+
+// WithClientValidation makes CreateUser and UpdateUser reject obviously
+// invalid input before calling the server, with the same InvalidArgument
+// code the server would return
+func WithClientValidation() Option {
+	return func(c *UserClient) {
+		c.validate = true
+	}
+}
+
+// validateUserFields applies the server's fixed rules for name, email and
+// age. Every problem found is listed in the returned error.
+func validateUserFields(name, email string, age int32) error {
+	var problems []string
+
+	if strings.TrimSpace(name) == "" {
+		problems = append(problems, "name is required")
+	} else if n := utf8.RuneCountInString(name); n > maxNameLength {
+		problems = append(problems, fmt.Sprintf("name must be at most %d characters (got %d)", maxNameLength, n))
+	}
+
+	if email == "" {
+		problems = append(problems, "email is required")
+	} else if n := utf8.RuneCountInString(email); n > maxEmailLength {
+		problems = append(problems, fmt.Sprintf("email must be at most %d characters (got %d)", maxEmailLength, n))
+	} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		problems = append(problems, "email is not a valid address")
+	}
+
+	if age < minAge || age > maxAge {
+		problems = append(problems, fmt.Sprintf("age must be between %d and %d", minAge, maxAge))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return status.Error(codes.InvalidArgument, "invalid request: "+strings.Join(problems, "; "))
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserClient_CreateUser_ClientValidation(t *testing.T) {
+	// No expectations: the request must not reach the server
+	mockClient := &MockUserServiceClient{}
+	client := &UserClient{client: mockClient}
+	WithClientValidation()(client)
+
+	user, err := client.CreateUser("  ", "john@example.com", 30)
+
+	assert.Nil(t, user)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "name is required")
+	mockClient.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserClient_CreateUser_ClientValidationOff(t *testing.T) {
+	mockClient := &MockUserServiceClient{}
+	mockClient.On("CreateUser", mock.Anything, &pb.CreateUserRequest{Name: "", Email: "john@example.com", Age: 30}, mock.Anything).
+		Return(nil, status.Error(codes.InvalidArgument, "invalid request: name is required"))
+	client := &UserClient{client: mockClient}
+
+	// Without the option the server decides
+	_, err := client.CreateUser("", "john@example.com", 30)
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockClient.AssertExpectations(t)
+}
+
+func TestValidateUserFields(t *testing.T) {
+	require.NoError(t, validateUserFields("John Doe", "john@example.com", 30))
+
+	err := validateUserFields("John Doe", "John <john@example.com>", 151)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "email is not a valid address; age must be between 0 and 150")
+}
@@ -0,0 +1,86 @@
+// Package config loads optional settings from a YAML or JSON file named by the
+// CONFIG_FILE environment variable. The file is a flat map of the same names
+// used as environment variables, e.g.
+//
+//	MYSQL_DSN: "user:password@tcp(localhost:3306)/dbname"
+//	LOCK_TYPE: redis
+//	MAX_PAGE_LIMIT: 200
+//
+// Values are exported into the process environment, so every component keeps
+// reading its settings with os.Getenv. Variables that are already set in the
+// environment take precedence over the file.
+//
+// Packages that read settings in init() import this package (blank import if
+// needed) so the file is loaded before they run.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+var logger = logrus.New()
+
+func init() {
+	logger.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+	})
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return
+	}
+	applied, err := Load(path)
+	if err != nil {
+		logger.WithError(err).WithField("config_file", path).Fatal("Failed to load config file")
+	}
+	logger.WithFields(logrus.Fields{
+		"config_file": path,
+		"applied":     applied,
+	}).Info("Config file loaded")
+}
+
+// Load reads the config file at path and sets every entry that is not
+// already present in the environment. It returns the names that were applied.
+// Files ending in .json are parsed as JSON, anything else as YAML.
+func Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &values)
+	} else {
+		err = yaml.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var applied []string
+	for key, value := range values {
+		name := strings.ToUpper(key)
+		switch value.(type) {
+		case string, bool, int, int64, float64:
+		default:
+			return nil, fmt.Errorf("config key %s: expected a scalar value, got %T", key, value)
+		}
+		if os.Getenv(name) != "" {
+			continue // the environment wins
+		}
+		if err := os.Setenv(name, fmt.Sprint(value)); err != nil {
+			return nil, fmt.Errorf("config key %s: %w", key, err)
+		}
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv blanks the given variables for the duration of the test so values
+// exported by Load are restored afterwards
+func clearEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	clearEnv(t, "LOCK_TYPE", "MAX_PAGE_LIMIT", "HEALTHCHECK_EXTERNAL", "GRPC_MAX_CONNECTION_AGE")
+	path := writeFile(t, "config.yaml", `
+LOCK_TYPE: redis
+MAX_PAGE_LIMIT: 200
+HEALTHCHECK_EXTERNAL: on
+grpc_max_connection_age: 30m
+`)
+
+	applied, err := Load(path)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"LOCK_TYPE", "MAX_PAGE_LIMIT", "HEALTHCHECK_EXTERNAL", "GRPC_MAX_CONNECTION_AGE"}, applied)
+	assert.Equal(t, "redis", os.Getenv("LOCK_TYPE"))
+	assert.Equal(t, "200", os.Getenv("MAX_PAGE_LIMIT"))
+	assert.Equal(t, "on", os.Getenv("HEALTHCHECK_EXTERNAL"))
+	assert.Equal(t, "30m", os.Getenv("GRPC_MAX_CONNECTION_AGE"))
+}
+
+func TestLoad_JSON(t *testing.T) {
+	clearEnv(t, "LOCK_TYPE", "MAX_PAGE_LIMIT")
+	path := writeFile(t, "config.json", `{"LOCK_TYPE": "etcd", "MAX_PAGE_LIMIT": 50}`)
+
+	_, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "etcd", os.Getenv("LOCK_TYPE"))
+	assert.Equal(t, "50", os.Getenv("MAX_PAGE_LIMIT"))
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	clearEnv(t, "MAX_PAGE_LIMIT")
+	t.Setenv("LOCK_TYPE", "etcd")
+	path := writeFile(t, "config.yaml", "LOCK_TYPE: redis\nMAX_PAGE_LIMIT: 200\n")
+
+	applied, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"MAX_PAGE_LIMIT"}, applied)
+	assert.Equal(t, "etcd", os.Getenv("LOCK_TYPE"))
+	assert.Equal(t, "200", os.Getenv("MAX_PAGE_LIMIT"))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	_, err = Load(writeFile(t, "bad.json", `{"LOCK_TYPE": `))
+	assert.Error(t, err)
+
+	_, err = Load(writeFile(t, "nested.yaml", "REDIS:\n  ADDR: localhost:6379\n"))
+	assert.Error(t, err)
+}
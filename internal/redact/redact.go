@@ -0,0 +1,45 @@
+// Package redact masks personally identifiable information before it is
+// written to logs. Redaction is controlled by the LOG_REDACT_PII environment
+// variable ("on" enables it) and is shared by the server and client.
+package redact
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+
+	_ "go-grpc-server-client/internal/config" // load CONFIG_FILE before reading LOG_REDACT_PII
+)
+
+var enabled atomic.Bool
+
+func init() {
+	if v := os.Getenv("LOG_REDACT_PII"); strings.ToLower(v) == "on" {
+		enabled.Store(true)
+	}
+}
+
+// Enabled reports whether PII redaction is active
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// SetEnabled turns PII redaction on or off at runtime
+func SetEnabled(on bool) {
+	enabled.Store(on)
+}
+
+// Email returns the address unchanged when redaction is off, otherwise it
+// keeps the first character of the local part and the domain, e.g.
+// "john@example.com" becomes "j***@example.com"
+func Email(email string) string {
+	if !Enabled() || email == "" {
+		return email
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
@@ -0,0 +1,31 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmail(t *testing.T) {
+	defer SetEnabled(Enabled())
+
+	tests := []struct {
+		name    string
+		enabled bool
+		email   string
+		want    string
+	}{
+		{name: "disabled", enabled: false, email: "john@example.com", want: "john@example.com"},
+		{name: "masked", enabled: true, email: "john@example.com", want: "j***@example.com"},
+		{name: "single character local part", enabled: true, email: "j@example.com", want: "j***@example.com"},
+		{name: "not an address", enabled: true, email: "john", want: "***"},
+		{name: "empty", enabled: true, email: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetEnabled(tt.enabled)
+			assert.Equal(t, tt.want, Email(tt.email))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sony/gobreaker"
+)
+
+// errLockContended marks a lock attempt that reached a healthy backend but
+// found the lock held by someone else until the caller's deadline. Lockers
+// wrap contention with it so that the breaker can tell a busy user from a
+// degraded backend.
+var errLockContended = errors.New("lock held by another request")
+
+// breakerLocker wraps a DistributedLocker in a circuit breaker. When Redis or
+// etcd is degraded, every lock attempt would otherwise wait for the full lock
+// timeout; once failureThreshold consecutive attempts fail, the breaker opens
+// and LockUser/LockUsers fail immediately with gobreaker.ErrOpenState until
+// openTimeout has passed and a probe request succeeds. Only backend failures
+// count; waiting out a held lock (errLockContended) does not.
+type breakerLocker struct {
+	DistributedLocker
+	cb *gobreaker.CircuitBreaker
+}
+
+func newBreakerLocker(locker DistributedLocker, failureThreshold uint32, openTimeout time.Duration) *breakerLocker {
+	logger.WithFields(logrus.Fields{
+		"failure_threshold": failureThreshold,
+		"open_timeout":      openTimeout.String(),
+	}).Info("Enabling circuit breaker for distributed locker")
+
+	return &breakerLocker{
+		DistributedLocker: locker,
+		cb: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "distributed-locker",
+			Timeout: openTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= failureThreshold
+			},
+			// A caller giving up, or a lock that is simply busy, says nothing
+			// about the lock backend
+			IsSuccessful: func(err error) bool {
+				return err == nil || errors.Is(err, context.Canceled) || errors.Is(err, errLockContended)
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				logger.WithFields(logrus.Fields{
+					"breaker": name,
+					"from":    from.String(),
+					"to":      to.String(),
+				}).Warn("Lock circuit breaker state changed")
+			},
+		}),
+	}
+}
+
+func (l *breakerLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
+	return l.execute(func() (UnlockFunc, error) {
+		return l.DistributedLocker.LockUser(ctx, userID)
+	})
+}
+
+func (l *breakerLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	return l.execute(func() (UnlockFunc, error) {
+		return l.DistributedLocker.LockUsers(ctx, userIDs)
+	})
+}
+
+func (l *breakerLocker) execute(lock func() (UnlockFunc, error)) (UnlockFunc, error) {
+	unlock, err := l.cb.Execute(func() (interface{}, error) {
+		return lock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unlock.(UnlockFunc), nil
+}
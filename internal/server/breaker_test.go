@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerLocker_OpensAfterConsecutiveFailures(t *testing.T) {
+	inner := &MockDistributedLocker{}
+	inner.On("LockUser", mock.Anything, int32(1)).Return(nil, fmt.Errorf("redis: connection refused"))
+	locker := newBreakerLocker(inner, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := locker.LockUser(context.Background(), 1)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, gobreaker.ErrOpenState)
+	}
+
+	// The breaker is open: further attempts fail fast without reaching the backend
+	_, err := locker.LockUser(context.Background(), 1)
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	_, err = locker.LockUsers(context.Background(), []int32{1, 2})
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	inner.AssertNumberOfCalls(t, "LockUser", 3)
+	inner.AssertNotCalled(t, "LockUsers")
+}
+
+func TestBreakerLocker_HalfOpensAfterTimeout(t *testing.T) {
+	inner := &MockDistributedLocker{}
+	inner.On("LockUser", mock.Anything, int32(1)).Return(nil, fmt.Errorf("redis: connection refused")).Once()
+	inner.On("LockUser", mock.Anything, int32(1)).Return(func() {}, nil)
+	locker := newBreakerLocker(inner, 1, 50*time.Millisecond)
+
+	_, err := locker.LockUser(context.Background(), 1)
+	require.Error(t, err)
+	_, err = locker.LockUser(context.Background(), 1)
+	require.ErrorIs(t, err, gobreaker.ErrOpenState)
+
+	// After the cool-down a probe is let through and, on success, closes the breaker
+	time.Sleep(100 * time.Millisecond)
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	unlock()
+	assert.Equal(t, gobreaker.StateClosed, locker.cb.State())
+}
+
+func TestBreakerLocker_IgnoresCallerCancellation(t *testing.T) {
+	inner := &MockDistributedLocker{}
+	inner.On("LockUser", mock.Anything, int32(1)).Return(nil, fmt.Errorf("lock wait aborted: %w", context.Canceled))
+	locker := newBreakerLocker(inner, 1, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := locker.LockUser(context.Background(), 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Equal(t, gobreaker.StateClosed, locker.cb.State())
+}
+
+func TestBreakerLocker_IgnoresLockContention(t *testing.T) {
+	inner := &MockDistributedLocker{}
+	inner.On("LockUser", mock.Anything, int32(1)).Return(nil, fmt.Errorf("%w: %w", errLockContended, redsync.ErrFailed))
+	locker := newBreakerLocker(inner, 1, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := locker.LockUser(context.Background(), 1)
+		assert.ErrorIs(t, err, errLockContended)
+	}
+	assert.Equal(t, gobreaker.StateClosed, locker.cb.State())
+	inner.AssertNumberOfCalls(t, "LockUser", 3)
+}
+
+func TestBreakerLocker_RedisContentionDoesNotTrip(t *testing.T) {
+	mr := miniredis.RunT(t)
+	locker := newBreakerLocker(NewRedsyncLocker(mr.Addr()), 1, time.Minute)
+
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	defer unlock()
+
+	// The held lock times out every waiter, but Redis itself is fine
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		_, err := locker.LockUser(ctx, 1)
+		cancel()
+		assert.ErrorIs(t, err, errLockContended)
+	}
+	assert.Equal(t, gobreaker.StateClosed, locker.cb.State())
+
+	// Losing Redis is a backend failure and opens the breaker
+	mr.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = locker.LockUser(ctx, 2)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errLockContended)
+	assert.Equal(t, gobreaker.StateOpen, locker.cb.State())
+}
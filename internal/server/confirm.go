@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	pb "go-grpc-server-client/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requireDeleteConfirm makes DeleteUser reject requests without a current
+// confirm_token (REQUIRE_DELETE_CONFIRM=on)
+var requireDeleteConfirm bool
+
+// deleteConfirmToken is the token GetUser issues for a user as last seen. It
+// hashes every mutable field as well as updated_at, whose one-second
+// resolution would otherwise let an edit within the same second keep the
+// token valid, so a delete confirmed against an older read is refused. It
+// guards against operator mistakes, not against forgery.
+func deleteConfirmToken(u *pb.User) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%s\x00%d\x00%s", u.Id, u.Name, u.Email, u.Age, u.UpdatedAt)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkDeleteConfirmToken verifies token against the user's current state.
+// It must be called with the user's lock held. found is false when the user
+// does not exist.
+func (s *UserServer) checkDeleteConfirmToken(ctx context.Context, id int32, token string) (found bool, err error) {
+	if token == "" {
+		return false, status.Error(codes.FailedPrecondition, "confirm_token required: read the user with GetUser first")
+	}
+
+	user := pb.User{Id: id}
+	err = s.db.QueryRowContext(ctx, `SELECT name, email, age, updated_at FROM users WHERE id = ?`, id).
+		Scan(&user.Name, &user.Email, &user.Age, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if token != deleteConfirmToken(&user) {
+		return true, status.Error(codes.FailedPrecondition, "stale confirm_token: the user changed since it was read")
+	}
+	return true, nil
+}
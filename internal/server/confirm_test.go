@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserServer_DeleteUser_ConfirmToken(t *testing.T) {
+	defer func(v bool) { requireDeleteConfirm = v }(requireDeleteConfirm)
+	requireDeleteConfirm = true
+
+	read := &pb.User{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30, UpdatedAt: "2023-01-01T00:00:00Z"}
+	edited := func(edit func(u *pb.User)) *pb.User {
+		u := proto.Clone(read).(*pb.User)
+		edit(u)
+		return u
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		current     *pb.User
+		wantCode    codes.Code
+		wantDeleted bool
+	}{
+		{name: "matching token", token: deleteConfirmToken(read), current: read, wantCode: codes.OK, wantDeleted: true},
+		{name: "stale token", token: deleteConfirmToken(read), current: edited(func(u *pb.User) { u.UpdatedAt = "2023-01-02T00:00:00Z" }), wantCode: codes.FailedPrecondition},
+		// An edit within the same second leaves updated_at as it was
+		{name: "edited in the same second", token: deleteConfirmToken(read), current: edited(func(u *pb.User) { u.Email = "jane@example.com" }), wantCode: codes.FailedPrecondition},
+		{name: "absent token", token: "", wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, sqlMock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			server := NewUserServerWithDB(db, newMemoryLocker())
+
+			if tt.current != nil {
+				sqlMock.ExpectQuery(`SELECT name, email, age, updated_at FROM users WHERE id = \?`).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"name", "email", "age", "updated_at"}).
+						AddRow(tt.current.Name, tt.current.Email, tt.current.Age, tt.current.UpdatedAt))
+			}
+			if tt.wantDeleted {
+				sqlMock.ExpectExec(`DELETE FROM users`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			got, err := server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1, ConfirmToken: tt.token})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantDeleted {
+				assert.True(t, got.Success)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserServer_GetUser_IssuesConfirmToken(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"))
+
+	got, err := server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, deleteConfirmToken(got.User), got.ConfirmToken)
+	assert.NotEqual(t, deleteConfirmToken(&pb.User{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30, UpdatedAt: "2023-01-01T00:00:00Z"}), got.ConfirmToken)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// usersCountTTL is how long ListUsers reuses a SELECT COUNT(*) result
+// (USERS_COUNT_CACHE_TTL). Zero counts on every request.
+var usersCountTTL = 5 * time.Second
+
+// userCountCache holds the last total row count seen by ListUsers. Local
+// creates and deletes invalidate it; changes made through other server
+// instances only show up once the entry expires.
+type userCountCache struct {
+	mu        sync.Mutex
+	count     int64
+	fetchedAt time.Time
+	valid     bool
+}
+
+// get returns the cached count if it is younger than ttl
+func (c *userCountCache) get(now time.Time, ttl time.Duration) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.valid || now.Sub(c.fetchedAt) >= ttl {
+		return 0, false
+	}
+	return c.count, true
+}
+
+func (c *userCountCache) set(count int64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count = count
+	c.fetchedAt = now
+	c.valid = true
+}
+
+func (c *userCountCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid = false
+}
+
+// countUsers returns the number of users for ListUsers' total. approximate
+// is true when the value was served from the cache and may lag behind writes
+// made through other instances.
+func (s *UserServer) countUsers(ctx context.Context) (count int64, approximate bool, err error) {
+	now := time.Now()
+	if n, ok := s.userCount.get(now, usersCountTTL); ok {
+		return n, true, nil
+	}
+
+	err = s.queryRowRead(ctx, func(row *sql.Row) error {
+		return row.Scan(&count)
+	}, `SELECT COUNT(*) FROM users`)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if usersCountTTL > 0 {
+		s.userCount.set(count, now)
+	}
+	return count, false, nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserServer_ListUsers_CachesTotalCount(t *testing.T) {
+	defer func(ttl time.Duration) { usersCountTTL = ttl }(usersCountTTL)
+	usersCountTTL = time.Minute
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	}
+	req := &pb.ListUsersRequest{Page: 1, Limit: 1}
+
+	// First list counts
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+	first, err := server.ListUsers(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(42), first.Total)
+	assert.False(t, first.TotalApproximate)
+
+	// Second list within the TTL reuses the count
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+	second, err := server.ListUsers(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(42), second.Total)
+	assert.True(t, second.TotalApproximate)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// A create invalidates the cache
+	sqlMock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(43, 1))
+	_, err = server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 28})
+	require.NoError(t, err)
+
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(43))
+	third, err := server.ListUsers(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(43), third.Total)
+	assert.False(t, third.TotalApproximate)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserCountCache_Expires(t *testing.T) {
+	var c userCountCache
+	now := time.Now()
+
+	_, ok := c.get(now, time.Second)
+	assert.False(t, ok)
+
+	c.set(7, now)
+	n, ok := c.get(now.Add(500*time.Millisecond), time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), n)
+
+	_, ok = c.get(now.Add(time.Second), time.Second)
+	assert.False(t, ok)
+
+	c.set(7, now)
+	c.invalidate()
+	_, ok = c.get(now, time.Second)
+	assert.False(t, ok)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error returned when a statement exceeds max_execution_time
+const mysqlErrStatementTimeout = 3024
+
+// MySQL error returned when an insert or update violates a unique index
+const mysqlErrDupEntry = 1062
+
+// isDuplicateEntry reports whether err is a MySQL unique index violation
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry
+}
+
+// mysqlTLSConfigName is the name under which the MYSQL_TLS_* configuration is
+// registered with the MySQL driver and referenced from the DSN
+const mysqlTLSConfigName = "custom-ca"
+
+// Connection settings enforced on every DSN. Timestamps are stored and parsed
+// in UTC and text uses utf8mb4 so that names outside the BMP survive.
+const requiredCollation = "utf8mb4_unicode_ci"
+
+// buildDSN normalizes MYSQL_DSN into the DSN used to open the MySQL pool.
+// It always sets parseTime=true, loc=UTC and a utf8mb4 collation, logging a
+// warning when the original DSN asked for something else, so users don't
+// have to remember these parameters. A positive statementTimeout is passed as
+// the max_execution_time session variable so that MySQL itself aborts
+// read-only SELECTs running longer than the budget, even if the client-side
+// context has not expired. The driver applies DSN system variables on every
+// new connection, so the whole pool is covered. When MYSQL_TLS_CA,
+// MYSQL_TLS_CERT or MYSQL_TLS_KEY is set, the resulting TLS configuration is
+// registered with the driver and the DSN's tls parameter points at it.
+func buildDSN(dsn string, statementTimeout time.Duration) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid MYSQL_DSN: %w", err)
+	}
+	explicit := dsnParams(dsn)
+
+	if !cfg.ParseTime {
+		if explicit.Has("parseTime") {
+			logger.WithField("parseTime", explicit.Get("parseTime")).Warn("Overriding MYSQL_DSN parameter: parseTime=true is required")
+		}
+		cfg.ParseTime = true
+	}
+
+	if cfg.Loc != time.UTC {
+		logger.WithField("loc", cfg.Loc.String()).Warn("Overriding MYSQL_DSN parameter: loc=UTC is required")
+		cfg.Loc = time.UTC
+	}
+
+	if !explicit.Has("collation") {
+		cfg.Collation = requiredCollation
+	} else if !strings.HasPrefix(cfg.Collation, "utf8mb4_") {
+		logger.WithField("collation", cfg.Collation).Warn("Overriding MYSQL_DSN parameter: a utf8mb4 collation is required")
+		cfg.Collation = requiredCollation
+	}
+
+	tlsConfig, err := mysqlTLSConfig(os.Getenv("MYSQL_TLS_CA"), os.Getenv("MYSQL_TLS_CERT"), os.Getenv("MYSQL_TLS_KEY"))
+	if err != nil {
+		return "", err
+	}
+	if tlsConfig != nil {
+		if explicit.Has("tls") {
+			logger.WithField("tls", explicit.Get("tls")).Warn("Overriding MYSQL_DSN parameter: tls is configured by MYSQL_TLS_CA/MYSQL_TLS_CERT/MYSQL_TLS_KEY")
+		}
+		if err := mysql.RegisterTLSConfig(mysqlTLSConfigName, tlsConfig); err != nil {
+			return "", fmt.Errorf("failed to register MySQL TLS config: %w", err)
+		}
+		cfg.TLSConfig = mysqlTLSConfigName
+	}
+
+	if statementTimeout > 0 {
+		if cfg.Params == nil {
+			cfg.Params = make(map[string]string)
+		}
+		cfg.Params["max_execution_time"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// dsnParams returns the query parameters written in a DSN
+func dsnParams(dsn string) url.Values {
+	i := strings.LastIndex(dsn, "?")
+	if i < 0 {
+		return url.Values{}
+	}
+	params, err := url.ParseQuery(dsn[i+1:])
+	if err != nil {
+		return url.Values{}
+	}
+	return params
+}
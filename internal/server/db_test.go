@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go-grpc-server-client/internal/testutil"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDSN_StatementTimeout(t *testing.T) {
+	dsn, err := buildDSN("user:pass@tcp(localhost:3306)/testdb", 1500*time.Millisecond)
+	require.NoError(t, err)
+
+	cfg, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "1500", cfg.Params["max_execution_time"])
+	assert.Equal(t, "testdb", cfg.DBName)
+
+	dsn, err = buildDSN("user:pass@tcp(localhost:3306)/testdb", 0)
+	require.NoError(t, err)
+	cfg, err = mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Params, "max_execution_time")
+
+	_, err = buildDSN("not a dsn", 0)
+	assert.Error(t, err)
+}
+
+func TestBuildDSN_RequiredParams(t *testing.T) {
+	t.Run("bare DSN", func(t *testing.T) {
+		dsn, err := buildDSN("user:pass@tcp(localhost:3306)/testdb", 0)
+		require.NoError(t, err)
+		assert.Contains(t, dsn, "parseTime=true")
+		assert.Contains(t, dsn, "collation="+requiredCollation)
+
+		cfg, err := mysql.ParseDSN(dsn)
+		require.NoError(t, err)
+		assert.True(t, cfg.ParseTime)
+		assert.Equal(t, time.UTC, cfg.Loc)
+		assert.Equal(t, requiredCollation, cfg.Collation)
+	})
+
+	t.Run("conflicting parameters are overridden", func(t *testing.T) {
+		dsn, err := buildDSN("user:pass@tcp(localhost:3306)/testdb?parseTime=false&loc=Local&collation=latin1_swedish_ci", 0)
+		require.NoError(t, err)
+
+		cfg, err := mysql.ParseDSN(dsn)
+		require.NoError(t, err)
+		assert.True(t, cfg.ParseTime)
+		assert.Equal(t, time.UTC, cfg.Loc)
+		assert.Equal(t, requiredCollation, cfg.Collation)
+	})
+
+	t.Run("utf8mb4 collation is kept", func(t *testing.T) {
+		dsn, err := buildDSN("user:pass@tcp(localhost:3306)/testdb?collation=utf8mb4_bin", 0)
+		require.NoError(t, err)
+
+		cfg, err := mysql.ParseDSN(dsn)
+		require.NoError(t, err)
+		assert.Equal(t, "utf8mb4_bin", cfg.Collation)
+	})
+}
+
+func TestBuildDSN_MySQLTLS(t *testing.T) {
+	certs := testutil.WriteTestCerts(t)
+	defer mysql.DeregisterTLSConfig(mysqlTLSConfigName)
+
+	t.Run("not configured", func(t *testing.T) {
+		dsn, err := buildDSN("user:pass@tcp(localhost:3306)/testdb", 0)
+		require.NoError(t, err)
+		assert.NotContains(t, dsn, "tls=")
+	})
+
+	t.Run("custom CA and client certificate", func(t *testing.T) {
+		t.Setenv("MYSQL_TLS_CA", certs.CAFile)
+		t.Setenv("MYSQL_TLS_CERT", certs.ClientCertFile)
+		t.Setenv("MYSQL_TLS_KEY", certs.ClientKeyFile)
+
+		dsn, err := buildDSN("user:pass@tcp(db.internal:3306)/testdb?tls=skip-verify", 0)
+		require.NoError(t, err)
+		assert.Contains(t, dsn, "tls="+mysqlTLSConfigName)
+
+		// Parsing resolves the name against the driver's registry
+		cfg, err := mysql.ParseDSN(dsn)
+		require.NoError(t, err)
+		require.NotNil(t, cfg.TLS)
+		assert.NotNil(t, cfg.TLS.RootCAs)
+		assert.Len(t, cfg.TLS.Certificates, 1)
+		assert.False(t, cfg.TLS.InsecureSkipVerify)
+		assert.Equal(t, "db.internal", cfg.TLS.ServerName)
+	})
+
+	t.Run("unreadable CA", func(t *testing.T) {
+		t.Setenv("MYSQL_TLS_CA", certs.CAFile+".missing")
+
+		_, err := buildDSN("user:pass@tcp(localhost:3306)/testdb", 0)
+		assert.ErrorContains(t, err, "MySQL TLS")
+	})
+}
+
+// Integration: set MYSQL_TEST_DSN to run against a real MySQL server, e.g.
+// MYSQL_TEST_DSN="testuser:testpass@tcp(localhost:3306)/testdb" (make docker-run)
+func TestBuildDSN_StatementTimeoutAbortsSlowQuery(t *testing.T) {
+	baseDSN := os.Getenv("MYSQL_TEST_DSN")
+	if baseDSN == "" {
+		t.Skip("MYSQL_TEST_DSN not set")
+	}
+
+	dsn, err := buildDSN(baseDSN, 100*time.Millisecond)
+	require.NoError(t, err)
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A cartesian product over information_schema runs far longer than 100ms
+	var n int64
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.columns a, information_schema.columns b, information_schema.columns c`).Scan(&n)
+
+	var mysqlErr *mysql.MySQLError
+	require.True(t, errors.As(err, &mysqlErr), "expected MySQL error, got %v", err)
+	assert.Equal(t, uint16(mysqlErrStatementTimeout), mysqlErr.Number)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// dbPoolInUseTrailer reports the MySQL connections in use when a call
+// finished, so that clients can shed load while the server is saturated
+const dbPoolInUseTrailer = "x-db-pool-inuse"
+
+// dbStatser is implemented by *sql.DB
+type dbStatser interface {
+	Stats() sql.DBStats
+}
+
+// dbPoolTrailer returns the x-db-pool-inuse trailer, or nil before the
+// database is connected
+func dbPoolTrailer() metadata.MD {
+	db, ok := mainDB.(dbStatser)
+	if !ok {
+		return nil
+	}
+	return metadata.Pairs(dbPoolInUseTrailer, strconv.Itoa(db.Stats().InUse))
+}
+
+// dbPoolUnaryInterceptor attaches the x-db-pool-inuse trailer to unary calls
+func dbPoolUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if md := dbPoolTrailer(); md != nil {
+		grpc.SetTrailer(ctx, md)
+	}
+	return resp, err
+}
+
+// dbPoolStreamInterceptor attaches the x-db-pool-inuse trailer to streams
+func dbPoolStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	if md := dbPoolTrailer(); md != nil {
+		ss.SetTrailer(md)
+	}
+	return err
+}
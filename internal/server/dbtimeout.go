@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// dbQueryTimeout bounds each handler's database work separately from the RPC
+// deadline, leaving headroom to release locks and encode the response
+// (DB_QUERY_TIMEOUT). Zero lets queries use the whole RPC deadline.
+var dbQueryTimeout time.Duration
+
+// withDBTimeout derives the context for a handler's SQL calls. A deadline
+// already closer than dbQueryTimeout is kept.
+func withDBTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if dbQueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, dbQueryTimeout)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDBTimeout(t *testing.T) {
+	defer func(d time.Duration) { dbQueryTimeout = d }(dbQueryTimeout)
+
+	dbQueryTimeout = 0
+	ctx, cancel := withDBTimeout(context.Background())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+	assert.Error(t, ctx.Err())
+
+	// A closer RPC deadline wins
+	dbQueryTimeout = time.Minute
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+	defer parentCancel()
+	ctx, cancel = withDBTimeout(parent)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+func TestUserServer_GetUser_DBQueryTimeout(t *testing.T) {
+	defer func(d time.Duration) { dbQueryTimeout = d }(dbQueryTimeout)
+	dbQueryTimeout = 100 * time.Millisecond
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WillDelayFor(2 * time.Second).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = server.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Less(t, elapsed, time.Second, "query should stop at DB_QUERY_TIMEOUT")
+	assert.NoError(t, ctx.Err(), "the RPC deadline should still have time left")
+}
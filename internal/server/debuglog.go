@@ -0,0 +1,59 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// debugTrailersEnabled lets callers request their own logs back
+// (DEBUG_TRAILERS_ENABLED)
+var debugTrailersEnabled bool
+
+const (
+	// debugHeader is the request metadata flag asking for debug logs
+	debugHeader = "x-debug"
+	// debugLogTrailer carries the request's log lines, one value per line
+	debugLogTrailer = "x-debug-log"
+)
+
+// debugLogKey marks a request context with its debug logger
+type debugLogKey struct{}
+
+// debugRequested reports whether the caller set x-debug: true
+func debugRequested(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(debugHeader) {
+		if v == "true" || v == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// debugLogUnaryInterceptor collects the logs of x-debug requests and
+// returns them in the x-debug-log trailer. The lines are still written to
+// the regular log output, sampled or not.
+func debugLogUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !debugRequested(ctx) {
+		return handler(ctx, req)
+	}
+
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(io.MultiWriter(logger.Out, &buf))
+	l.SetFormatter(logger.Formatter)
+	l.SetLevel(logger.GetLevel())
+
+	resp, err := handler(context.WithValue(ctx, debugLogKey{}, l), req)
+
+	if lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"); lines[0] != "" {
+		grpc.SetTrailer(ctx, metadata.MD{debugLogTrailer: lines})
+	}
+	return resp, err
+}
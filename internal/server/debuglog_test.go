@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// debugGetUser calls GetUser for user 1 through the configured interceptor
+// chain and returns the response trailer
+func debugGetUser(t *testing.T, md metadata.MD) metadata.MD {
+	return debugGetUserRows(t, md, sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+}
+
+// debugGetUserRows is debugGetUser with the rows the user lookup returns
+func debugGetUserRows(t *testing.T, md metadata.MD, rows *sqlmock.Rows) metadata.MD {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(interceptorOptions(serverInterceptorStages())...)
+	pb.RegisterUserServiceServer(s, NewUserServerWithDB(db, newMemoryLocker()))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var trailer metadata.MD
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	_, err = pb.NewUserServiceClient(conn).GetUser(ctx, &pb.GetUserRequest{Id: 1}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	return trailer
+}
+
+func TestDebugLogTrailer(t *testing.T) {
+	defer func(v bool) { debugTrailersEnabled = v }(debugTrailersEnabled)
+	debugTrailersEnabled = true
+
+	lines := debugGetUser(t, metadata.Pairs(debugHeader, "true")).Get(debugLogTrailer)
+	require.NotEmpty(t, lines)
+	log := strings.Join(lines, "\n")
+	assert.Contains(t, log, "GetUser request received")
+	assert.Contains(t, log, "User retrieved successfully")
+}
+
+func TestDebugLogTrailer_IncludesWarnings(t *testing.T) {
+	defer func(v bool) { debugTrailersEnabled = v }(debugTrailersEnabled)
+	debugTrailersEnabled = true
+
+	lines := debugGetUserRows(t, metadata.Pairs(debugHeader, "true"), sqlmock.NewRows(userColumns)).Get(debugLogTrailer)
+	assert.Contains(t, strings.Join(lines, "\n"), "User not found")
+}
+
+func TestDebugLogTrailer_NotRequested(t *testing.T) {
+	defer func(v bool) { debugTrailersEnabled = v }(debugTrailersEnabled)
+	debugTrailersEnabled = true
+
+	assert.Empty(t, debugGetUser(t, nil).Get(debugLogTrailer))
+}
+
+func TestDebugLogTrailer_Disabled(t *testing.T) {
+	defer func(v bool) { debugTrailersEnabled = v }(debugTrailersEnabled)
+	debugTrailersEnabled = false
+
+	assert.Empty(t, debugGetUser(t, metadata.Pairs(debugHeader, "true")).Get(debugLogTrailer))
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// etcdSessionReuse backs all etcd locks of a process with one long-lived
+// session instead of creating a lease per lock (ETCD_SESSION_REUSE)
+var etcdSessionReuse bool
+
+// etcdSharedSession holds the long-lived session of an EtcdLocker and the
+// in-process locks that keep its users apart: etcd mutexes on the same
+// session share an owner key, so etcd alone can't exclude them from each other
+type etcdSharedSession struct {
+	mu      sync.Mutex
+	session *concurrency.Session
+	local   map[string]*etcdLocalLock
+}
+
+// etcdLocalLock serializes the holders of one lock key within the process
+type etcdLocalLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// sessionOptions returns the options for sessions bound to ctx
+func (l *EtcdLocker) sessionOptions(ctx context.Context) []concurrency.SessionOption {
+	opts := []concurrency.SessionOption{concurrency.WithContext(ctx)}
+	if l.sessionTTL > 0 {
+		opts = append(opts, concurrency.WithTTL(l.sessionTTL))
+	}
+	return opts
+}
+
+// sharedSession returns the session backing all locks, creating it on first
+// use and again once its lease is lost. The session keeps its lease alive
+// until Close.
+func (l *EtcdLocker) sharedSession() (*concurrency.Session, error) {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+
+	if l.shared.session != nil {
+		select {
+		case <-l.shared.session.Done():
+			logger.WithField("lease_id", int64(l.shared.session.Lease())).Warn("etcd lock session expired, creating a new one")
+			l.shared.session = nil
+		default:
+			return l.shared.session, nil
+		}
+	}
+
+	sess, err := concurrency.NewSession(l.client, l.sessionOptions(context.Background())...)
+	if err != nil {
+		return nil, err
+	}
+	logger.WithField("lease_id", int64(sess.Lease())).Info("etcd lock session created")
+	l.shared.session = sess
+	return sess, nil
+}
+
+// etcdUnlockTimeout bounds deleting a lock key, which runs on a fresh
+// context since the request that took the lock may already be done
+const etcdUnlockTimeout = 5 * time.Second
+
+// resetSharedSession revokes sess after one of its lock keys could not be
+// deleted. With a shared session the lease would otherwise keep that key,
+// and so the user's lock, held until the process exits. Revoking frees
+// every key of the session; its other holders stay excluded in-process by
+// their local locks. The next lock creates a new session.
+func (l *EtcdLocker) resetSharedSession(sess *concurrency.Session) {
+	l.shared.mu.Lock()
+	if l.shared.session == sess {
+		l.shared.session = nil
+	}
+	l.shared.mu.Unlock()
+
+	logger.WithField("lease_id", int64(sess.Lease())).Warn("Revoking etcd lock session to free an unreleased lock")
+	if err := sess.Close(); err != nil {
+		logger.WithError(err).Warn("Failed to revoke etcd lock session")
+	}
+}
+
+// lockLocal waits for the in-process lock on key; the returned function
+// releases it
+func (l *EtcdLocker) lockLocal(ctx context.Context, key string) (func(), error) {
+	l.shared.mu.Lock()
+	if l.shared.local == nil {
+		l.shared.local = make(map[string]*etcdLocalLock)
+	}
+	ll := l.shared.local[key]
+	if ll == nil {
+		ll = &etcdLocalLock{ch: make(chan struct{}, 1)}
+		l.shared.local[key] = ll
+	}
+	ll.refs++
+	l.shared.mu.Unlock()
+
+	drop := func() {
+		l.shared.mu.Lock()
+		if ll.refs--; ll.refs == 0 {
+			delete(l.shared.local, key)
+		}
+		l.shared.mu.Unlock()
+	}
+
+	select {
+	case ll.ch <- struct{}{}:
+		return func() {
+			<-ll.ch
+			drop()
+		}, nil
+	case <-ctx.Done():
+		drop()
+		return nil, ctx.Err()
+	}
+}
+
+// Close revokes the shared session, releasing its locks at once rather
+// than after the lease TTL, and closes the etcd client
+func (l *EtcdLocker) Close() error {
+	l.shared.mu.Lock()
+	sess := l.shared.session
+	l.shared.session = nil
+	l.shared.mu.Unlock()
+
+	if sess != nil {
+		if err := sess.Close(); err != nil {
+			logger.WithError(err).Warn("Failed to revoke etcd lock session")
+		}
+	}
+	return l.client.Close()
+}
+
+// closeLocker releases the resources of the configured locker on shutdown
+func closeLocker(locker DistributedLocker) {
+	if b, ok := locker.(*breakerLocker); ok {
+		locker = b.DistributedLocker
+	}
+	if c, ok := locker.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			logger.WithError(err).Warn("Failed to close locker")
+		}
+	}
+}
+
+// etcdLockContended reports whether a Mutex.Lock error means the key stayed
+// held until the deadline while the session (and so etcd) was still alive
+func etcdLockContended(sess *concurrency.Session, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	select {
+	case <-sess.Done():
+		return false
+	default:
+		return true
+	}
+}
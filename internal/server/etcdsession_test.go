@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	etcdserverpb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// keepAliveLease grants leases and keeps each alive until its session is
+// closed or expire is called, enough to create etcd sessions without an etcd
+// server
+type keepAliveLease struct {
+	clientv3.Lease
+	mu     sync.Mutex
+	grants int
+	// expireLatest ends the keep-alive of the latest lease
+	expireLatest func()
+}
+
+func (l *keepAliveLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.grants++
+	return &clientv3.LeaseGrantResponse{ID: clientv3.LeaseID(l.grants), TTL: ttl}, nil
+}
+
+func (l *keepAliveLease) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	ch := make(chan *clientv3.LeaseKeepAliveResponse)
+	var once sync.Once
+	end := func() { once.Do(func() { close(ch) }) }
+	go func() {
+		<-ctx.Done()
+		end()
+	}()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireLatest = end
+	return ch, nil
+}
+
+func (l *keepAliveLease) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (l *keepAliveLease) Close() error { return nil }
+
+// expire ends the keep-alive of the latest lease, as when it is lost
+func (l *keepAliveLease) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expireLatest()
+}
+
+// lockKV grants every etcd mutex at once and records the keys deleted by
+// Unlock. Deletes fail with their ctx's error, or with deleteErr if set.
+type lockKV struct {
+	clientv3.KV
+	mu        sync.Mutex
+	deleted   []string
+	deleteErr error
+}
+
+func (kv *lockKV) Txn(ctx context.Context) clientv3.Txn { return grantTxn{} }
+
+func (kv *lockKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if kv.deleteErr != nil {
+		return nil, kv.deleteErr
+	}
+	kv.deleted = append(kv.deleted, key)
+	return &clientv3.DeleteResponse{}, nil
+}
+
+// grantTxn answers a mutex's acquire transaction with "no other owner"
+type grantTxn struct{}
+
+func (grantTxn) If(...clientv3.Cmp) clientv3.Txn  { return grantTxn{} }
+func (grantTxn) Then(...clientv3.Op) clientv3.Txn { return grantTxn{} }
+func (grantTxn) Else(...clientv3.Op) clientv3.Txn { return grantTxn{} }
+func (grantTxn) Commit() (*clientv3.TxnResponse, error) {
+	return &clientv3.TxnResponse{
+		Header:    &etcdserverpb.ResponseHeader{Revision: 1},
+		Succeeded: true,
+		Responses: []*etcdserverpb.ResponseOp{
+			{Response: &etcdserverpb.ResponseOp_ResponsePut{ResponsePut: &etcdserverpb.PutResponse{}}},
+			{Response: &etcdserverpb.ResponseOp_ResponseRange{ResponseRange: &etcdserverpb.RangeResponse{}}},
+		},
+	}, nil
+}
+
+func TestEtcdLocker_SessionReuse_UnlockAfterCancel(t *testing.T) {
+	lease := &keepAliveLease{}
+	kv := &lockKV{}
+	cli := clientv3.NewCtxClient(context.Background())
+	cli.Lease, cli.KV = lease, kv
+	locker := &EtcdLocker{client: cli, keyPrefix: "test", reuseSession: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlock, err := locker.LockUser(ctx, 1)
+	require.NoError(t, err)
+
+	// The request is gone before it releases the lock
+	cancel()
+	unlock()
+
+	require.Len(t, kv.deleted, 1)
+	assert.True(t, strings.HasPrefix(kv.deleted[0], "/test/user-lock-1/"), kv.deleted[0])
+	assert.NotNil(t, locker.shared.session, "a clean unlock keeps the shared session")
+	assert.Equal(t, 1, lease.grants)
+}
+
+func TestEtcdLocker_SessionReuse_FailedUnlockRevokesSession(t *testing.T) {
+	lease := &keepAliveLease{}
+	kv := &lockKV{deleteErr: errors.New("etcdserver: request timed out")}
+	cli := clientv3.NewCtxClient(context.Background())
+	cli.Lease, cli.KV = lease, kv
+	locker := &EtcdLocker{client: cli, keyPrefix: "test", reuseSession: true}
+
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	sess := locker.shared.session
+	unlock()
+
+	// The lease holding the undeleted key is revoked and replaced on next use
+	<-sess.Done()
+	assert.Nil(t, locker.shared.session)
+	assert.Empty(t, locker.shared.local)
+
+	kv.mu.Lock()
+	kv.deleteErr = nil
+	kv.mu.Unlock()
+	unlock, err = locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	unlock()
+	assert.Equal(t, 2, lease.grants)
+}
+
+func TestEtcdLocker_SharedSession(t *testing.T) {
+	lease := &keepAliveLease{}
+	cli := clientv3.NewCtxClient(context.Background())
+	cli.Lease = lease
+	locker := &EtcdLocker{client: cli, reuseSession: true}
+
+	first, err := locker.sharedSession()
+	require.NoError(t, err)
+	second, err := locker.sharedSession()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, lease.grants)
+
+	lease.expire()
+	<-first.Done()
+
+	renewed, err := locker.sharedSession()
+	require.NoError(t, err)
+	assert.NotSame(t, first, renewed)
+	assert.Equal(t, 2, lease.grants)
+
+	locker.Close()
+	assert.Nil(t, locker.shared.session)
+}
+
+func TestEtcdLocker_LockLocal(t *testing.T) {
+	locker := &EtcdLocker{}
+
+	release, err := locker.lockLocal(context.Background(), "/user-lock-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.lockLocal(ctx, "/user-lock-1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	other, err := locker.lockLocal(context.Background(), "/user-lock-2")
+	require.NoError(t, err)
+	other()
+
+	release()
+	release, err = locker.lockLocal(context.Background(), "/user-lock-1")
+	require.NoError(t, err)
+	release()
+	assert.Empty(t, locker.shared.local)
+}
+
+// Integration: set ETCD_TEST_ENDPOINTS to run against a real etcd server, e.g.
+// ETCD_TEST_ENDPOINTS=localhost:2379 (make docker-run)
+func TestEtcdLocker_SessionReuse(t *testing.T) {
+	endpoints := os.Getenv("ETCD_TEST_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("ETCD_TEST_ENDPOINTS not set")
+	}
+
+	newLocker := func() *EtcdLocker {
+		l, err := openEtcdLocker(strings.Split(endpoints, ","))
+		require.NoError(t, err)
+		l.keyPrefix = "session-reuse-test"
+		l.reuseSession = true
+		t.Cleanup(func() { l.Close() })
+		return l
+	}
+	// Two lockers stand in for two server processes
+	a, b := newLocker(), newLocker()
+	ctx := context.Background()
+
+	for _, id := range []int32{1, 2, 1} {
+		unlock, err := a.LockUser(ctx, id)
+		require.NoError(t, err)
+		unlock()
+	}
+	sess, err := a.sharedSession()
+	require.NoError(t, err)
+	lease := sess.Lease()
+
+	var inside, overlaps, total atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, l := range []*EtcdLocker{a, b} {
+			wg.Add(1)
+			go func(l *EtcdLocker) {
+				defer wg.Done()
+				unlock, err := l.LockUser(ctx, 1)
+				if !assert.NoError(t, err) {
+					return
+				}
+				if inside.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				inside.Add(-1)
+				total.Add(1)
+				unlock()
+			}(l)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(40), total.Load())
+	assert.Zero(t, overlaps.Load(), "lock holders overlapped")
+	sess, err = a.sharedSession()
+	require.NoError(t, err)
+	assert.Equal(t, lease, sess.Lease(), "all locks should share one session")
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userEventBufferSize is the number of events a WatchUsers subscriber may
+// fall behind before it is disconnected
+const userEventBufferSize = 64
+
+// userEventBroker fans user change events out to WatchUsers subscribers
+// within this process.
+//
+// Delivery is at-most-once: handlers publish only after their mutation has
+// been committed, each subscriber receives only events published while it is
+// subscribed, and events are not persisted. Mutations made by other server
+// instances are not observed.
+//
+// Publishing never blocks the mutation handler. Each subscriber has a bounded
+// buffer; when it is full the event is dropped (counted in
+// watch_events_dropped_total) and the subscriber is disconnected, so that a
+// client never silently holds a view with gaps in it. A client that is
+// disconnected or reconnects must re-read state (e.g. ListUsers) to cover
+// anything it missed.
+type userEventBroker struct {
+	mu   sync.RWMutex
+	subs map[*userEventSubscriber]struct{}
+
+	// closed is closed on shutdown so that every WatchUsers stream returns
+	// and GracefulStop is not held open by them
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// userEventSubscriber is a single WatchUsers stream's queue
+type userEventSubscriber struct {
+	events chan *pb.UserEvent
+
+	// lagged is closed when an event had to be dropped for this subscriber
+	lagged     chan struct{}
+	laggedOnce sync.Once
+}
+
+func newUserEventBroker() *userEventBroker {
+	return &userEventBroker{
+		subs:   make(map[*userEventSubscriber]struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// close ends every current and future WatchUsers stream. It is safe to call
+// more than once.
+func (b *userEventBroker) close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// subscribe registers a new subscriber. The returned cancel function must be
+// called once the subscriber stops reading.
+func (b *userEventBroker) subscribe() (*userEventSubscriber, func()) {
+	sub := &userEventSubscriber{
+		events: make(chan *pb.UserEvent, userEventBufferSize),
+		lagged: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	activeWatchers.Inc()
+
+	return sub, func() {
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+		activeWatchers.Dec()
+	}
+}
+
+// subscribers returns the number of active subscribers
+func (b *userEventBroker) subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// publish delivers ev to every subscriber without blocking. Subscribers whose
+// buffer is full lose the event and are marked as lagged.
+func (b *userEventBroker) publish(ev *pb.UserEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.events <- ev:
+		default:
+			watchEventsDroppedTotal.Inc()
+			sub.laggedOnce.Do(func() {
+				logger.WithFields(logrus.Fields{
+					"event_type": ev.Type.String(),
+					"user_id":    ev.User.GetId(),
+				}).Warn("WatchUsers subscriber buffer full, disconnecting slow subscriber")
+				close(sub.lagged)
+			})
+		}
+	}
+}
+
+// newUserEvent builds an event stamped with the current time
+func newUserEvent(typ pb.UserEvent_Type, user *pb.User) *pb.UserEvent {
+	return &pb.UserEvent{
+		Type:       typ,
+		User:       user,
+		OccurredAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// WatchUsers streams user change events until the client cancels the call
+func (s *UserServer) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserService_WatchUsersServer) error {
+	sub, cancel := s.events.subscribe()
+	defer cancel()
+
+	logger.Info("WatchUsers subscriber connected")
+	defer logger.Info("WatchUsers subscriber disconnected")
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, "server shutting down, watch again")
+		case <-sub.lagged:
+			return status.Error(codes.ResourceExhausted, "subscriber too slow: events were dropped, re-read state and watch again")
+		case ev := <-sub.events:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserServer_WatchUsers_ReceivesCreateEvent(t *testing.T) {
+	db := &MockDB{}
+	result := &MockResult{}
+	result.On("LastInsertId").Return(int64(7), nil)
+	db.On("ExecContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(result, nil)
+	userServer := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, userServer)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewUserServiceClient(conn).WatchUsers(ctx, &pb.WatchUsersRequest{})
+	require.NoError(t, err)
+
+	// Events published before the subscription is registered are not delivered
+	require.Eventually(t, func() bool { return userServer.events.subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	created, err := userServer.CreateUser(ctx, &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	ev, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.UserEvent_CREATED, ev.Type)
+	assert.True(t, proto.Equal(created.User, ev.User))
+	assert.NotEmpty(t, ev.OccurredAt)
+
+	cancel()
+	assert.Eventually(t, func() bool { return userServer.events.subscribers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestUserServer_WatchUsers_SlowSubscriberDoesNotBlockWrites(t *testing.T) {
+	db := &MockDB{}
+	result := &MockResult{}
+	result.On("LastInsertId").Return(int64(1), nil)
+	db.On("ExecContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(result, nil)
+	userServer := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	// A subscriber that never reads
+	sub, cancel := userServer.events.subscribe()
+	defer cancel()
+
+	before := promtestutil.ToFloat64(watchEventsDroppedTotal)
+	const writes = userEventBufferSize + 10
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < writes; i++ {
+			_, err := userServer.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+			assert.NoError(t, err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("CreateUser blocked on a slow WatchUsers subscriber")
+	}
+
+	assert.Equal(t, float64(writes-userEventBufferSize), promtestutil.ToFloat64(watchEventsDroppedTotal)-before)
+	assert.Len(t, sub.events, userEventBufferSize)
+	select {
+	case <-sub.lagged:
+	default:
+		t.Fatal("slow subscriber was not marked for disconnection")
+	}
+}
+
+func TestActiveWatchers(t *testing.T) {
+	userServer := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, userServer)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	base := promtestutil.ToFloat64(activeWatchers)
+	watching := func(n int) func() bool {
+		return func() bool { return promtestutil.ToFloat64(activeWatchers) == base+float64(n) }
+	}
+
+	var cancels []context.CancelFunc
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := pb.NewUserServiceClient(conn).WatchUsers(ctx, &pb.WatchUsersRequest{})
+		require.NoError(t, err)
+		cancels = append(cancels, cancel)
+	}
+	require.Eventually(t, watching(3), time.Second, 10*time.Millisecond)
+
+	cancels[0]()
+	assert.Eventually(t, watching(2), time.Second, 10*time.Millisecond)
+
+	cancels[1]()
+	cancels[2]()
+	assert.Eventually(t, watching(0), time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+// csvChunkSize is the size at which buffered CSV rows are sent as a chunk
+const csvChunkSize = 32 * 1024
+
+// csvRecord formats the projected columns of user as a CSV record
+func csvRecord(user *pb.User, columns []string) []string {
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		switch c {
+		case "id":
+			record[i] = strconv.Itoa(int(user.Id))
+		case "name":
+			record[i] = user.Name
+		case "email":
+			record[i] = user.Email
+		case "age":
+			record[i] = strconv.Itoa(int(user.Age))
+		case "created_at":
+			record[i] = user.CreatedAt
+		case "updated_at":
+			record[i] = user.UpdatedAt
+		}
+	}
+	return record
+}
+
+// ExportUsersCSV streams the users table as CSV, header line first. Rows are
+// sent in chunks of about csvChunkSize as they are scanned, so the export is
+// never held in memory as a whole.
+func (s *UserServer) ExportUsersCSV(req *pb.ExportUsersCSVRequest, stream pb.UserService_ExportUsersCSVServer) error {
+	ctx := stream.Context()
+	logger.WithField("fields", req.Fields).Info("ExportUsersCSV request received")
+
+	columns, err := userProjection(req.Fields)
+	if err != nil {
+		logger.WithField("fields", req.Fields).Warn("Invalid ExportUsersCSV fields")
+		return err
+	}
+	var afterID int32
+	if req.PageToken != "" {
+		if afterID, err = decodePageToken(req.PageToken); err != nil {
+			logger.WithField("page_token", req.PageToken).Warn("Invalid ExportUsersCSV page token")
+			return err
+		}
+	}
+
+	done := timeDBQuery(dbOpList)
+	rows, err := s.queryRead(ctx, `SELECT `+strings.Join(columns, ", ")+` FROM users WHERE id > ? ORDER BY id ASC`, afterID)
+	done()
+	if err != nil {
+		logger.WithError(err).Error("Database error in ExportUsersCSV")
+		return err
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	flush := func() error {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if buf.Len() == 0 {
+			return nil
+		}
+		err := stream.Send(&pb.CSVChunk{Data: bytes.Clone(buf.Bytes())})
+		buf.Reset()
+		return err
+	}
+
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	var exported int
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			logger.WithError(err).WithField("exported_users", exported).Warn("ExportUsersCSV cancelled during scan")
+			return status.FromContextError(err).Err()
+		}
+
+		var user pb.User
+		if err := rows.Scan(userScanTargets(&user, columns)...); err != nil {
+			logger.WithError(err).Error("Error scanning user row in ExportUsersCSV")
+			return err
+		}
+		if err := w.Write(csvRecord(&user, columns)); err != nil {
+			return err
+		}
+		exported++
+
+		if buf.Len() >= csvChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("Error iterating user rows in ExportUsersCSV")
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"exported_users": exported,
+		"columns":        len(columns),
+	}).Info("Users exported as CSV")
+	return nil
+}
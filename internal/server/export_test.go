@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// exportCSV runs ExportUsersCSV over a real gRPC connection and returns the
+// concatenated output and the number of chunks received
+func exportCSV(t *testing.T, userServer *UserServer, req *pb.ExportUsersCSVRequest) ([]byte, int, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, userServer)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := pb.NewUserServiceClient(conn).ExportUsersCSV(context.Background(), req)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	var chunks int
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return out.Bytes(), chunks, nil
+		}
+		if err != nil {
+			return out.Bytes(), chunks, err
+		}
+		out.Write(chunk.Data)
+		chunks++
+	}
+}
+
+func TestUserServer_ExportUsersCSV(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	const n = 1000
+	rows := sqlmock.NewRows(userColumns)
+	for id := 1; id <= n; id++ {
+		rows.AddRow(id, fmt.Sprintf("User, %d", id), fmt.Sprintf("user%d@example.com", id), 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	}
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id > \? ORDER BY id ASC`).
+		WithArgs(0).
+		WillReturnRows(rows)
+
+	out, chunks, err := exportCSV(t, NewUserServerWithDB(db, newMemoryLocker()), &pb.ExportUsersCSVRequest{})
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, n+1)
+	assert.Equal(t, userColumns, records[0])
+	assert.Equal(t, []string{"1", "User, 1", "user1@example.com", "30", "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"}, records[1])
+	assert.Greater(t, chunks, 1, "export should be streamed in several chunks")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ExportUsersCSV_FieldsAndPageToken(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectQuery(`SELECT id, email FROM users WHERE id > \? ORDER BY id ASC`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(6, "six@example.com").AddRow(7, "seven@example.com"))
+
+	out, _, err := exportCSV(t, NewUserServerWithDB(db, newMemoryLocker()), &pb.ExportUsersCSVRequest{
+		Fields:    []string{"email"},
+		PageToken: encodePageToken(5),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "id,email\n6,six@example.com\n7,seven@example.com\n", string(out))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ExportUsersCSV_UnknownField(t *testing.T) {
+	_, _, err := exportCSV(t, NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{}), &pb.ExportUsersCSVRequest{Fields: []string{"password"}})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Fault injection for chaos testing, never for production: a faultRate
+// fraction of UserService calls fails with one of faultCodes, picked at
+// random, before reaching the handler (FAULT_INJECT, FAULT_INJECT_RATE).
+// Health checks are left alone so the server stays in rotation.
+var (
+	faultCodes []codes.Code
+	faultRate  float64
+)
+
+// parseFaultCodes parses a comma-separated list of gRPC code names such as
+// "UNAVAILABLE,DEADLINE_EXCEEDED"
+func parseFaultCodes(v string) ([]codes.Code, error) {
+	var cs []codes.Code
+	for _, name := range strings.Split(v, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(strconv.Quote(name))); err != nil || c == codes.OK {
+			return nil, fmt.Errorf("%q is not a gRPC error code", name)
+		}
+		cs = append(cs, c)
+	}
+	if len(cs) == 0 {
+		return nil, fmt.Errorf("no error codes given")
+	}
+	return cs, nil
+}
+
+// injectedFault returns the error to fail method with, or nil to let the
+// call through
+func injectedFault(method string) error {
+	if !strings.HasPrefix(method, "/"+pb.UserService_ServiceDesc.ServiceName+"/") || rand.Float64() >= faultRate {
+		return nil
+	}
+	c := faultCodes[rand.IntN(len(faultCodes))]
+	logger.WithFields(logrus.Fields{
+		"method": method,
+		"code":   c.String(),
+	}).Warn("Injecting fault")
+	return status.Errorf(c, "injected fault (FAULT_INJECT)")
+}
+
+// faultInjectionUnaryInterceptor fails unary calls at the configured rate
+func faultInjectionUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := injectedFault(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// faultInjectionStreamInterceptor fails streams at the configured rate
+// before the handler sends anything
+func faultInjectionStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := injectedFault(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestFaultInjection(t *testing.T) {
+	defer func(cs []codes.Code, r float64) { faultCodes, faultRate = cs, r }(faultCodes, faultRate)
+	faultCodes, faultRate = []codes.Code{codes.Unavailable}, 1
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(interceptorOptions(serverInterceptorStages())...)
+	// The handler must never run: MockDB fails any unexpected call
+	pb.RegisterUserServiceServer(s, NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{}))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = pb.NewUserServiceClient(conn).GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	stream, err := pb.NewUserServiceClient(conn).ExportUsersCSV(context.Background(), &pb.ExportUsersCSVRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Health checks are never failed on purpose
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+func TestParseFaultCodes(t *testing.T) {
+	cs, err := parseFaultCodes("unavailable, DEADLINE_EXCEEDED")
+	require.NoError(t, err)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, cs)
+
+	for _, v := range []string{"OK", "NOT_A_CODE", " , "} {
+		_, err := parseFaultCodes(v)
+		assert.Error(t, err, v)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Timeouts of the metrics/health HTTP server. The DB ping gets its own,
+// shorter budget (HEALTHCHECK_DB_TIMEOUT) so a hung database can't hold
+// /healthz requests open.
+var (
+	healthReadTimeout   = 5 * time.Second
+	healthWriteTimeout  = 10 * time.Second
+	healthDBPingTimeout = 2 * time.Second
+)
+
+// pprofEnabled mounts the net/http/pprof handlers at /debug/pprof/ on the
+// metrics server (PPROF_ENABLED)
+var pprofEnabled bool
+
+// healthPinger is the part of *sql.DB used by the health check
+type healthPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// newMetricsServer serves Prometheus metrics at /metrics, the health check at
+// /healthz, the hot-user debug view at /hotusers and, if enabled, profiles at
+// /debug/pprof/ with bounded read/write timeouts
+func newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/hotusers", hotUsersHandler)
+	if pprofEnabled {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: healthReadTimeout,
+		ReadTimeout:       healthReadTimeout,
+		WriteTimeout:      healthWriteTimeout,
+	}
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	if draining.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("draining"))
+		return
+	}
+	if mainDB != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), healthDBPingTimeout)
+		defer cancel()
+		if err := mainDB.PingContext(ctx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("db error: ping timed out after " + healthDBPingTimeout.String()))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("db error: " + err.Error()))
+			return
+		}
+		if q, ok := mainDB.(schemaQuerier); ok && checkSchemaHealth {
+			if err := checkUsersSchema(ctx, q); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("schema error: " + err.Error()))
+				return
+			}
+		}
+	}
+	if checkExternalHealth && globalLocker != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		if err := globalLocker.HealthCheck(ctx); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("external error: " + err.Error()))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// blockingPinger simulates a half-open DB socket: Ping never returns on its own
+type blockingPinger struct{}
+
+func (blockingPinger) PingContext(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type okPinger struct{}
+
+func (okPinger) PingContext(ctx context.Context) error { return nil }
+
+func TestHealthz_BlockingDBPing(t *testing.T) {
+	defer func(db healthPinger, timeout time.Duration) {
+		mainDB, healthDBPingTimeout = db, timeout
+	}(mainDB, healthDBPingTimeout)
+	mainDB = blockingPinger{}
+	healthDBPingTimeout = 100 * time.Millisecond
+
+	ts := httptest.NewServer(newMetricsServer("").Handler)
+	defer ts.Close()
+
+	start := time.Now()
+	resp, err := http.Get(ts.URL + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	elapsed := time.Since(start)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "db error: ping timed out after 100ms", string(body))
+	assert.Less(t, elapsed, time.Second, "healthz must return shortly after the ping timeout")
+}
+
+func TestHealthz_OK(t *testing.T) {
+	defer func(db healthPinger) { mainDB = db }(mainDB)
+	mainDB = okPinger{}
+
+	rec := httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
+
+func TestNewMetricsServer_Pprof(t *testing.T) {
+	defer func(v bool) { pprofEnabled = v }(pprofEnabled)
+
+	for _, tt := range []struct {
+		name    string
+		enabled bool
+		want    int
+	}{
+		{name: "enabled", enabled: true, want: http.StatusOK},
+		{name: "disabled", enabled: false, want: http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pprofEnabled = tt.enabled
+
+			rec := httptest.NewRecorder()
+			newMetricsServer("").Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestNewMetricsServer_Timeouts(t *testing.T) {
+	srv := newMetricsServer(":2112")
+	assert.Equal(t, ":2112", srv.Addr)
+	assert.Equal(t, healthReadTimeout, srv.ReadTimeout)
+	assert.Equal(t, healthWriteTimeout, srv.WriteTimeout)
+}
+
+func TestDrainAndStop_HealthzUnavailableWhileRPCsSucceed(t *testing.T) {
+	defer func(db healthPinger) { mainDB = db }(mainDB)
+	mainDB = okPinger{}
+	defer draining.Store(false)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, stubGetUserServer{})
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(lis) }()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewUserServiceClient(conn)
+	_, err = client.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		drainAndStop(s, healthServer, nil, 500*time.Millisecond, 5*time.Second)
+		close(stopped)
+	}()
+
+	// During the drain window
+	require.Eventually(t, draining.Load, time.Second, 10*time.Millisecond)
+	rec := httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	hc, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, hc.Status)
+
+	_, err = client.GetUser(context.Background(), &pb.GetUserRequest{Id: 2})
+	assert.NoError(t, err)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after the drain delay")
+	}
+	assert.NoError(t, <-served)
+}
+
+func TestDrainAndStop_EndsOpenWatchStreams(t *testing.T) {
+	defer draining.Store(false)
+
+	userServer := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterUserServiceServer(s, userServer)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(lis) }()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := pb.NewUserServiceClient(conn).WatchUsers(context.Background(), &pb.WatchUsersRequest{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return userServer.events.subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		drainAndStop(s, nil, userServer.events.close, 0, time.Minute)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("GracefulStop held open by a WatchUsers stream")
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.NoError(t, <-served)
+}
+
+// hangingStopper never finishes GracefulStop until Stop is called
+type hangingStopper struct {
+	stop    chan struct{}
+	stopped bool
+}
+
+func (h *hangingStopper) GracefulStop() { <-h.stop }
+
+func (h *hangingStopper) Stop() {
+	h.stopped = true
+	close(h.stop)
+}
+
+func TestDrainAndStop_StopsAfterTimeout(t *testing.T) {
+	defer draining.Store(false)
+
+	h := &hangingStopper{stop: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		drainAndStop(h, nil, nil, 0, 50*time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("drainAndStop did not fall back to Stop")
+	}
+	assert.True(t, h.stopped)
+}
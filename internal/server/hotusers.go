@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Hot-user tracking keeps per-user access counts out of Prometheus, where a
+// user_id label would explode cardinality. Memory is bounded by
+// hotUsersCapacity tracked IDs per window.
+const (
+	hotUsersCapacity = 1000
+	hotUsersWindow   = time.Minute
+	hotUsersDefaultN = 10
+)
+
+// hotUsers records every user lock taken by the handlers
+var hotUsers = newHotUserTracker(hotUsersCapacity, hotUsersWindow)
+
+type hotUser struct {
+	UserID int32  `json:"user_id"`
+	Count  uint64 `json:"count"`
+}
+
+// hotUserTracker counts accesses per user over a sliding window made of the
+// current and the previous fixed window. When the current window already
+// tracks capacity IDs, a new ID replaces the least-accessed one and inherits
+// its count (the Space-Saving algorithm), so frequent users are never lost
+// while rare ones may be over-counted.
+type hotUserTracker struct {
+	mu          sync.Mutex
+	capacity    int
+	window      time.Duration
+	windowStart time.Time
+	current     map[int32]uint64
+	previous    map[int32]uint64
+	now         func() time.Time
+}
+
+func newHotUserTracker(capacity int, window time.Duration) *hotUserTracker {
+	return &hotUserTracker{
+		capacity: capacity,
+		window:   window,
+		current:  make(map[int32]uint64),
+		now:      time.Now,
+	}
+}
+
+// rotate starts a new window when the current one has ended. Callers must hold h.mu.
+func (h *hotUserTracker) rotate(now time.Time) {
+	elapsed := now.Sub(h.windowStart)
+	if elapsed < h.window {
+		return
+	}
+	if elapsed < 2*h.window {
+		h.previous = h.current
+	} else {
+		h.previous = nil
+	}
+	h.current = make(map[int32]uint64)
+	h.windowStart = now.Truncate(h.window)
+}
+
+func (h *hotUserTracker) record(userID int32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.rotate(h.now())
+
+	if _, ok := h.current[userID]; ok || len(h.current) < h.capacity {
+		h.current[userID]++
+		return
+	}
+
+	var minID int32
+	var minCount uint64
+	first := true
+	for id, count := range h.current {
+		if first || count < minCount {
+			minID, minCount, first = id, count, false
+		}
+	}
+	delete(h.current, minID)
+	h.current[userID] = minCount + 1
+}
+
+// top returns the n most-accessed users, most accessed first
+func (h *hotUserTracker) top(n int) []hotUser {
+	h.mu.Lock()
+	h.rotate(h.now())
+	counts := make(map[int32]uint64, len(h.current)+len(h.previous))
+	for id, count := range h.previous {
+		counts[id] += count
+	}
+	for id, count := range h.current {
+		counts[id] += count
+	}
+	h.mu.Unlock()
+
+	users := make([]hotUser, 0, len(counts))
+	for id, count := range counts {
+		users = append(users, hotUser{UserID: id, Count: count})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Count != users[j].Count {
+			return users[i].Count > users[j].Count
+		}
+		return users[i].UserID < users[j].UserID
+	})
+	if len(users) > n {
+		users = users[:n]
+	}
+	return users
+}
+
+// hotUsersHandler serves the most-accessed user IDs as JSON; ?n= sets how many
+func hotUsersHandler(w http.ResponseWriter, r *http.Request) {
+	n := hotUsersDefaultN
+	if v := r.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Window string    `json:"window"`
+		Users  []hotUser `json:"users"`
+	}{
+		Window: hotUsers.window.String(),
+		Users:  hotUsers.top(n),
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotUsersEndpoint_ReportsMostAccessed(t *testing.T) {
+	defer func(prev *hotUserTracker) { hotUsers = prev }(hotUsers)
+	hotUsers = newHotUserTracker(hotUsersCapacity, hotUsersWindow)
+
+	locker := &MockDistributedLocker{}
+	locker.On("LockUser", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("lock unavailable"))
+	server := NewUserServerWithDB(&MockDB{}, locker)
+
+	// Skewed traffic: user 7 is hot
+	for i := 0; i < 50; i++ {
+		server.GetUser(context.Background(), &pb.GetUserRequest{Id: 7})
+	}
+	for id := int32(1); id <= 5; id++ {
+		server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: id})
+	}
+	for i := 0; i < 3; i++ {
+		server.GetUser(context.Background(), &pb.GetUserRequest{Id: 2})
+	}
+
+	ts := httptest.NewServer(newMetricsServer("").Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/hotusers?n=2")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Window string    `json:"window"`
+		Users  []hotUser `json:"users"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []hotUser{{UserID: 7, Count: 50}, {UserID: 2, Count: 4}}, body.Users)
+	assert.Equal(t, "1m0s", body.Window)
+}
+
+func TestHotUsersEndpoint_InvalidN(t *testing.T) {
+	ts := httptest.NewServer(newMetricsServer("").Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/hotusers?n=zero")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHotUserTracker_BoundedCapacity(t *testing.T) {
+	tracker := newHotUserTracker(3, time.Minute)
+
+	for i := 0; i < 100; i++ {
+		tracker.record(1)
+	}
+	// A long tail of one-off IDs must not evict the heavy hitter
+	for id := int32(100); id < 200; id++ {
+		tracker.record(id)
+	}
+
+	assert.Len(t, tracker.current, 3)
+	top := tracker.top(1)
+	require.Len(t, top, 1)
+	assert.Equal(t, int32(1), top[0].UserID)
+	assert.Equal(t, uint64(100), top[0].Count)
+}
+
+func TestHotUserTracker_Window(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := newHotUserTracker(10, time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	tracker.record(1)
+	tracker.record(1)
+
+	// The previous window still counts
+	now = now.Add(time.Minute)
+	tracker.record(2)
+	assert.Equal(t, []hotUser{{UserID: 1, Count: 2}, {UserID: 2, Count: 1}}, tracker.top(10))
+
+	// Accesses older than two windows are dropped
+	now = now.Add(2 * time.Minute)
+	assert.Empty(t, tracker.top(10))
+}
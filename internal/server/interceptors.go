@@ -0,0 +1,115 @@
+package server
+
+import (
+	"sort"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"google.golang.org/grpc"
+)
+
+// Interceptor stages, one per interceptor and named after what it does
+const (
+	stageRecovery       = "recovery"
+	stageLogSampling    = "log-sampling"
+	stageDebugLog       = "debug-log"
+	stagePayloadLog     = "payload-log"
+	stageMetrics        = "metrics"
+	stageDBPoolTrailer  = "db-pool-trailer"
+	stageAuth           = "auth"
+	stageSizeLimit      = "size-limit"
+	stageFaultInjection = "fault-injection"
+	stageLockScope      = "lock-scope"
+)
+
+// interceptorOrder is the order of the server's interceptor chain, outermost
+// first. Requests pass through the stages in this order and responses in
+// reverse:
+//
+//	recovery → log-sampling → debug-log → payload-log → metrics →
+//	db-pool-trailer → auth → size-limit → fault-injection → lock-scope
+//
+// Recovery comes first so that a panic anywhere below it is turned into an
+// Internal error; the logging stages and metrics see every request, including
+// those rejected by the limits or an injected fault; the lock scope is only
+// created for admitted requests. auth is reserved for an authentication
+// interceptor and has none yet.
+//
+// Every interceptor has a stage of its own, so its position never depends on
+// the order it is appended in serverInterceptorStages.
+var interceptorOrder = []string{
+	stageRecovery, stageLogSampling, stageDebugLog, stagePayloadLog, stageMetrics,
+	stageDBPoolTrailer, stageAuth, stageSizeLimit, stageFaultInjection, stageLockScope,
+}
+
+// interceptorStage is one position in the chain. Either interceptor may be
+// nil when the stage does not apply to that kind of RPC.
+type interceptorStage struct {
+	name   string
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// serverInterceptorStages returns the configured stages in chain order. New
+// interceptors get a stage of their own in interceptorOrder; their position
+// comes from there, not from where they are appended.
+func serverInterceptorStages() []interceptorStage {
+	stages := []interceptorStage{
+		{name: stageRecovery, unary: recoveryUnaryInterceptor, stream: recoveryStreamInterceptor},
+		{name: stageMetrics, unary: grpc_prometheus.UnaryServerInterceptor, stream: grpc_prometheus.StreamServerInterceptor},
+		{name: stageDBPoolTrailer, unary: dbPoolUnaryInterceptor, stream: dbPoolStreamInterceptor},
+		{name: stageLockScope, unary: lockScopeUnaryInterceptor},
+	}
+	if len(maxRecvSizeByMethod) > 0 {
+		stages = append(stages, interceptorStage{name: stageSizeLimit, unary: recvSizeLimitUnaryInterceptor})
+	}
+	if len(faultCodes) > 0 && faultRate > 0 {
+		stages = append(stages, interceptorStage{name: stageFaultInjection, unary: faultInjectionUnaryInterceptor, stream: faultInjectionStreamInterceptor})
+	}
+	if logSampleRate < 1 {
+		stages = append(stages, interceptorStage{name: stageLogSampling, unary: logSamplingUnaryInterceptor})
+	}
+	if debugTrailersEnabled {
+		stages = append(stages, interceptorStage{name: stageDebugLog, unary: debugLogUnaryInterceptor})
+	}
+	if logPayloads {
+		stages = append(stages, interceptorStage{name: stagePayloadLog, unary: payloadLoggingUnaryInterceptor})
+	}
+	return orderInterceptorStages(stages)
+}
+
+// orderInterceptorStages sorts stages by interceptorOrder; unknown names go
+// last, in the order they were given.
+func orderInterceptorStages(stages []interceptorStage) []interceptorStage {
+	rank := func(name string) int {
+		for i, n := range interceptorOrder {
+			if n == name {
+				return i
+			}
+		}
+		return len(interceptorOrder)
+	}
+
+	ordered := append([]interceptorStage(nil), stages...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank(ordered[i].name) < rank(ordered[j].name)
+	})
+	return ordered
+}
+
+// interceptorOptions chains the stages' unary and stream interceptors
+func interceptorOptions(stages []interceptorStage) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	for _, st := range stages {
+		if st.unary != nil {
+			unary = append(unary, st.unary)
+		}
+		if st.stream != nil {
+			stream = append(stream, st.stream)
+		}
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestInterceptorOptions_RunInDocumentedOrder(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	record := func(name string) interceptorStage {
+		return interceptorStage{
+			name: name,
+			unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				mu.Lock()
+				ran = append(ran, name)
+				mu.Unlock()
+				return handler(ctx, req)
+			},
+		}
+	}
+
+	// Registered in reverse on purpose
+	var registered []interceptorStage
+	for i := len(interceptorOrder) - 1; i >= 0; i-- {
+		registered = append(registered, record(interceptorOrder[i]))
+	}
+	stages := orderInterceptorStages(registered)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(interceptorOptions(stages)...)
+	pb.RegisterUserServiceServer(s, stubGetUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = pb.NewUserServiceClient(conn).GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, interceptorOrder, ran)
+}
+
+func TestServerInterceptorStages(t *testing.T) {
+	defer func(v bool) { logPayloads = v }(logPayloads)
+	defer func(m map[string]int) { maxRecvSizeByMethod = m }(maxRecvSizeByMethod)
+	maxRecvSizeByMethod = nil
+
+	names := func() []string {
+		var out []string
+		for _, st := range serverInterceptorStages() {
+			out = append(out, st.name)
+		}
+		return out
+	}
+
+	logPayloads = false
+	assert.Equal(t, []string{stageRecovery, stageMetrics, stageDBPoolTrailer, stageLockScope}, names())
+
+	logPayloads = true
+	assert.Equal(t, []string{stageRecovery, stagePayloadLog, stageMetrics, stageDBPoolTrailer, stageLockScope}, names())
+
+	maxRecvSizeByMethod = map[string]int{"CreateUser": 1024}
+	assert.Equal(t, []string{stageRecovery, stagePayloadLog, stageMetrics, stageDBPoolTrailer, stageSizeLimit, stageLockScope}, names())
+}
+
+func TestServerInterceptorStages_DBPoolTrailer(t *testing.T) {
+	defer func(db healthPinger) { mainDB = db }(mainDB)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mainDB = db
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(interceptorOptions(serverInterceptorStages())...)
+	pb.RegisterUserServiceServer(s, stubGetUserServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var trailer metadata.MD
+	_, err = pb.NewUserServiceClient(conn).GetUser(context.Background(), &pb.GetUserRequest{Id: 1}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+
+	values := trailer.Get(dbPoolInUseTrailer)
+	require.Len(t, values, 1)
+	inUse, err := strconv.Atoi(values[0])
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, inUse, 0)
+}
+
+// stubGetUserServer answers GetUser without touching a database
+type stubGetUserServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (stubGetUserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	return &pb.GetUserResponse{User: &pb.User{Id: req.Id}, Success: true}, nil
+}
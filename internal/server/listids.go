@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+// ListUserIDs returns the ids of a page of users, selected like ListUsers but
+// without the cost of loading and serializing full records
+func (s *UserServer) ListUserIDs(ctx context.Context, req *pb.ListUserIDsRequest) (*pb.ListUserIDsResponse, error) {
+	requestLog(ctx).WithFields(logrus.Fields{
+		"page":  req.Page,
+		"limit": req.Limit,
+	}).Info("ListUserIDs request received")
+
+	page, limit := effectivePage(req.Page, req.Limit)
+	offset, err := pageOffset(page, limit)
+	if err != nil {
+		requestLog(ctx).WithFields(logrus.Fields{"page": page, "limit": limit}).Warn("ListUserIDs page out of range")
+		return nil, err
+	}
+
+	dbCtx, cancel := withDBTimeout(ctx)
+	defer cancel()
+	listCtx, listCancel := withListTimeout(dbCtx)
+	defer listCancel()
+
+	var rows *sql.Rows
+	done := timeDBQuery(dbOpList)
+	if req.PageToken != "" {
+		afterID, tokenErr := decodePageToken(req.PageToken)
+		if tokenErr != nil {
+			requestLog(ctx).WithField("page_token", req.PageToken).Warn("Invalid ListUserIDs page token")
+			return nil, tokenErr
+		}
+		rows, err = s.queryRead(listCtx, `SELECT id FROM users WHERE id > ? ORDER BY id ASC LIMIT ?`, afterID, limit)
+	} else {
+		rows, err = s.queryRead(listCtx, `SELECT id FROM users ORDER BY id ASC LIMIT ? OFFSET ?`, limit, offset)
+	}
+	done()
+	if err != nil {
+		if timeoutErr := listTimeoutError(ctx, listCtx, "ListUserIDs", err); timeoutErr != nil {
+			requestLog(ctx).WithError(err).Warn("ListUserIDs query timed out")
+			return nil, timeoutErr
+		}
+		requestLog(ctx).WithError(err).Error("Database error in ListUserIDs")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			requestLog(ctx).WithError(err).Error("Error scanning user id in ListUserIDs")
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		if timeoutErr := listTimeoutError(ctx, listCtx, "ListUserIDs", err); timeoutErr != nil {
+			requestLog(ctx).WithError(err).Warn("ListUserIDs query timed out")
+			return nil, timeoutErr
+		}
+		requestLog(ctx).WithError(err).Error("Error iterating user ids in ListUserIDs")
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, err
+	}
+
+	resp := &pb.ListUserIDsResponse{Ids: ids}
+	if int32(len(ids)) == limit {
+		resp.NextPageToken = encodePageToken(ids[len(ids)-1])
+	}
+
+	requestLog(ctx).WithField("page_ids", len(ids)).Info("User ids listed successfully")
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserServer_ListUserIDs(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	t.Run("page and limit", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT id FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+			WithArgs(3, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(5).AddRow(7))
+
+		got, err := server.ListUserIDs(context.Background(), &pb.ListUserIDsRequest{Page: 2, Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, []int32{4, 5, 7}, got.Ids)
+		assert.Equal(t, encodePageToken(7), got.NextPageToken)
+	})
+
+	t.Run("page token", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT id FROM users WHERE id > \? ORDER BY id ASC LIMIT \?`).
+			WithArgs(7, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+		got, err := server.ListUserIDs(context.Background(), &pb.ListUserIDsRequest{Limit: 3, PageToken: encodePageToken(7)})
+		require.NoError(t, err)
+		assert.Equal(t, []int32{9}, got.Ids)
+		assert.Empty(t, got.NextPageToken)
+	})
+
+	t.Run("invalid page token", func(t *testing.T) {
+		_, err := server.ListUserIDs(context.Background(), &pb.ListUserIDsRequest{PageToken: "not a token"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
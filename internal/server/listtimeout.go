@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// listQueryTimeout caps the page query of ListUsers and ListUserIDs, which can
+// scan far more rows than the single-user handlers (LIST_QUERY_TIMEOUT). Zero
+// leaves them under DB_QUERY_TIMEOUT alone.
+var listQueryTimeout time.Duration
+
+// withListTimeout derives the context for a list query from the handler's
+// database context
+func withListTimeout(dbCtx context.Context) (context.Context, context.CancelFunc) {
+	if listQueryTimeout <= 0 {
+		return context.WithCancel(dbCtx)
+	}
+	return context.WithTimeout(dbCtx, listQueryTimeout)
+}
+
+// listTimeoutError reports a list query that ran out of time on the server's
+// side, either at listCtx's deadline or at MySQL's max_execution_time, as
+// DeadlineExceeded with a hint for the caller. It returns nil for any other
+// error, including the caller's own deadline, which is handled as usual.
+func listTimeoutError(ctx, listCtx context.Context, rpc string, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	var mysqlErr *mysql.MySQLError
+	statementTimeout := errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrStatementTimeout
+	if !statementTimeout && !errors.Is(listCtx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	return status.Errorf(codes.DeadlineExceeded,
+		"%s query took too long; request a smaller limit or continue with page_token instead of a large page number", rpc)
+}
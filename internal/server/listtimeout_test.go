@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserServer_ListUsers_ListQueryTimeout(t *testing.T) {
+	defer func(d time.Duration) { listQueryTimeout = d }(listQueryTimeout)
+	listQueryTimeout = 100 * time.Millisecond
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+		WillDelayFor(2 * time.Second).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Limit: 10})
+	elapsed := time.Since(start)
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "page_token")
+	assert.Less(t, elapsed, time.Second, "query should stop at LIST_QUERY_TIMEOUT")
+	assert.NoError(t, ctx.Err(), "the RPC deadline should still have time left")
+}
+
+func TestUserServer_ListUserIDs_StatementTimeout(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT id FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+		WillReturnError(&mysql.MySQLError{Number: mysqlErrStatementTimeout, Message: "Query execution was interrupted, maximum statement execution time exceeded"})
+
+	_, err = server.ListUserIDs(context.Background(), &pb.ListUserIDsRequest{Page: 1, Limit: 10})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestListTimeoutError_CallerDeadline(t *testing.T) {
+	defer func(d time.Duration) { listQueryTimeout = d }(listQueryTimeout)
+	listQueryTimeout = time.Minute
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	listCtx, listCancel := withListTimeout(ctx)
+	defer listCancel()
+
+	// The caller's own deadline is not the server's list guard
+	assert.NoError(t, listTimeoutError(ctx, listCtx, "ListUsers", context.DeadlineExceeded))
+}
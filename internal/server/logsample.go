@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// logSampleRate is the fraction of requests whose per-request info logs are
+// written (LOG_SAMPLE_RATE). The others are only counted in
+// log_sampled_requests_total; warnings and errors are always logged.
+var logSampleRate = 1.0
+
+// sampledOutLogKey holds the logger of a request that was not sampled
+type sampledOutLogKey struct{}
+
+// sampledOutLogger returns the logger of a request that was not sampled: it
+// writes like the regular logger but drops everything below warning, so the
+// request's warnings and errors are still logged. It is built once per
+// request, by logSamplingUnaryInterceptor.
+func sampledOutLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(logger.Out)
+	l.SetFormatter(logger.Formatter)
+	level := logger.GetLevel()
+	if level > logrus.WarnLevel {
+		level = logrus.WarnLevel
+	}
+	l.SetLevel(level)
+	return l
+}
+
+// logSamplingUnaryInterceptor decides once per request whether its info logs
+// are written, and gives a request that was not sampled its warn-level logger
+func logSamplingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	sampled := rand.Float64() < logSampleRate
+	logSampledRequestsTotal.WithLabelValues(strconv.FormatBool(sampled)).Inc()
+	if !sampled {
+		ctx = context.WithValue(ctx, sampledOutLogKey{}, sampledOutLogger())
+	}
+	return handler(ctx, req)
+}
+
+// requestLog returns the logger for everything a handler logs about a
+// request: the debug logger of an x-debug request, so that warnings and
+// errors reach the x-debug-log trailer too, otherwise the regular logger,
+// limited to warnings and errors if the request was sampled out
+func requestLog(ctx context.Context) *logrus.Logger {
+	if l, ok := ctx.Value(debugLogKey{}).(*logrus.Logger); ok {
+		return l
+	}
+	if l, ok := ctx.Value(sampledOutLogKey{}).(*logrus.Logger); ok {
+		return l
+	}
+	return logger
+}
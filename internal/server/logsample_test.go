@@ -0,0 +1,58 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestLogSamplingUnaryInterceptor(t *testing.T) {
+	defer func(r float64) { logSampleRate = r }(logSampleRate)
+	logSampleRate = 0.25
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(os.Stderr)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.UserService/GetUser"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		requestLog(ctx).WithField("user_id", 1).Info("GetUser request received")
+		requestLog(ctx).Warn("User not found")
+		return nil, nil
+	}
+
+	const requests = 4000
+	for i := 0; i < requests; i++ {
+		_, err := logSamplingUnaryInterceptor(context.Background(), nil, info, handler)
+		require.NoError(t, err)
+	}
+
+	detailed := strings.Count(buf.String(), "GetUser request received")
+	assert.InDelta(t, requests*logSampleRate, detailed, requests*0.05)
+	// Warnings are never sampled out
+	assert.Equal(t, requests, strings.Count(buf.String(), "User not found"))
+}
+
+func TestRequestLog_WithoutSamplingDecision(t *testing.T) {
+	assert.Same(t, logger, requestLog(context.Background()))
+}
+
+func TestRequestLog_SampledOutLoggerIsBuiltOncePerRequest(t *testing.T) {
+	defer func(r float64) { logSampleRate = r }(logSampleRate)
+	logSampleRate = 0
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.UserService/GetUser"}
+	_, err := logSamplingUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		first := requestLog(ctx)
+		assert.NotSame(t, logger, first)
+		assert.Same(t, first, requestLog(ctx))
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Lock backend names used as the "type" label of lock metrics
+const (
+	lockTypeRedis = "redis"
+	lockTypeEtcd  = "etcd"
+)
+
+var (
+	lockOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lock_operations_total",
+		Help: "Total number of distributed lock acquisitions, partitioned by lock backend and result.",
+	}, []string{"type", "result"})
+
+	lockWaiters = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lock_waiters",
+		Help: "Number of requests currently waiting to acquire a user lock.",
+	})
+
+	getUserResultTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "get_user_result_total",
+		Help: "Total number of GetUser calls by outcome: found, not_found (business miss) or error.",
+	}, []string{"result"})
+
+	usersCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "users_created_total",
+		Help: "Total number of users created.",
+	})
+
+	usersDeletedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "users_deleted_total",
+		Help: "Total number of users deleted.",
+	})
+
+	usersTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "users_total",
+		Help: "Number of rows in the users table, refreshed periodically.",
+	})
+
+	watchEventsDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "watch_events_dropped_total",
+		Help: "Total number of WatchUsers events dropped because a subscriber's buffer was full.",
+	})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Time spent in MySQL per handler operation, excluding lock waits.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	logSampledRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_sampled_requests_total",
+		Help: "Total number of requests by whether their info logs were sampled (LOG_SAMPLE_RATE).",
+	}, []string{"sampled"})
+
+	activeWatchers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "active_watchers",
+		Help: "Current number of WatchUsers subscriptions.",
+	})
+
+	validationErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "validation_errors_total",
+		Help: "Total number of rejected user fields by field and reason.",
+	}, []string{"field", "reason"})
+
+	lockHoldDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "lock_hold_duration_seconds",
+		Help:    "Time a user lock is held by a handler, from acquisition to unlock.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// usersTotalRefreshInterval is how often users_total is recounted
+var usersTotalRefreshInterval = 30 * time.Second
+
+func init() {
+	prometheus.MustRegister(lockOperationsTotal, lockWaiters, getUserResultTotal, usersCreatedTotal, usersDeletedTotal, usersTotal, watchEventsDroppedTotal, dbQueryDuration, lockHoldDuration, logSampledRequestsTotal, validationErrorsTotal, activeWatchers)
+}
+
+// Lock acquisition outcomes used as the "result" label of lock_operations_total
+const (
+	lockResultSuccess   = "success"
+	lockResultTimeout   = "timeout"
+	lockResultCancelled = "cancelled"
+	lockResultError     = "error"
+)
+
+// lockResult classifies a lock acquisition error. The context is consulted
+// as well as err because redsync reports an expired context as a plain
+// ErrFailed rather than wrapping ctx.Err().
+func lockResult(ctx context.Context, err error) string {
+	switch {
+	case err == nil:
+		return lockResultSuccess
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return lockResultTimeout
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return lockResultCancelled
+	default:
+		return lockResultError
+	}
+}
+
+// recordLockOperation counts a single lock acquisition attempt
+func recordLockOperation(ctx context.Context, lockType string, err error) {
+	lockOperationsTotal.WithLabelValues(lockType, lockResult(ctx, err)).Inc()
+}
+
+// GetUser outcomes used as the "result" label of get_user_result_total
+const (
+	getUserFound    = "found"
+	getUserNotFound = "not_found"
+	getUserError    = "error"
+)
+
+// Handler operations used as the "operation" label of db_query_duration_seconds
+const (
+	dbOpGet    = "get"
+	dbOpList   = "list"
+	dbOpCreate = "create"
+	dbOpUpdate = "update"
+	dbOpDelete = "delete"
+)
+
+// timeDBQuery starts timing the database work of operation; call the
+// returned function when it is done
+func timeDBQuery(operation string) func() {
+	start := time.Now()
+	return func() {
+		dbQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// refreshUsersTotal sets the users_total gauge from SELECT COUNT(*)
+func refreshUsersTotal(ctx context.Context, db DBInterface) error {
+	var count int64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return err
+	}
+	usersTotal.Set(float64(count))
+	return nil
+}
+
+// runUsersTotalRefresher refreshes users_total every interval until ctx is done
+func runUsersTotalRefresher(ctx context.Context, db DBInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := refreshUsersTotal(ctx, db); err != nil && ctx.Err() == nil {
+			logger.WithError(err).Warn("Failed to refresh users_total metric")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
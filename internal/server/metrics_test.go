@@ -0,0 +1,348 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// findMetric scrapes the default registry and returns the sample of the named
+// metric family whose labels match exactly, or nil if there is none
+func findMetric(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if len(m.GetLabel()) != len(labels) {
+				continue
+			}
+			matched := true
+			for _, lp := range m.GetLabel() {
+				if labels[lp.GetName()] != lp.GetValue() {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+func TestLockOperationsTotal_RedisSuccessAndTimeout(t *testing.T) {
+	mr := miniredis.RunT(t)
+	locker := NewRedsyncLocker(mr.Addr())
+
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	defer unlock()
+
+	// The lock is still held, so a second attempt must fail once the context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = locker.LockUser(ctx, 1)
+	require.Error(t, err)
+
+	success := findMetric(t, "lock_operations_total", map[string]string{"type": "redis", "result": "success"})
+	if assert.NotNil(t, success) {
+		assert.GreaterOrEqual(t, success.GetCounter().GetValue(), float64(1))
+	}
+
+	timeout := findMetric(t, "lock_operations_total", map[string]string{"type": "redis", "result": "timeout"})
+	if assert.NotNil(t, timeout) {
+		assert.GreaterOrEqual(t, timeout.GetCounter().GetValue(), float64(1))
+	}
+}
+
+func TestLockOperationsTotal_RedisContextResults(t *testing.T) {
+	mr := miniredis.RunT(t)
+	locker := NewRedsyncLocker(mr.Addr())
+
+	counter := func(result string) float64 {
+		return promtestutil.ToFloat64(lockOperationsTotal.WithLabelValues(lockTypeRedis, result))
+	}
+
+	t.Run("expired context", func(t *testing.T) {
+		before := counter(lockResultTimeout)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := locker.LockUser(ctx, 2)
+		require.Error(t, err)
+		assert.Equal(t, before+1, counter(lockResultTimeout))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		before := counter(lockResultCancelled)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := locker.LockUser(ctx, 3)
+		require.Error(t, err)
+		assert.Equal(t, before+1, counter(lockResultCancelled))
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		before := counter(lockResultError)
+		locker.mutexOpts = []redsync.Option{redsync.WithTries(1)}
+		mr.Close()
+
+		_, err := locker.LockUser(context.Background(), 4)
+		require.Error(t, err)
+		assert.Equal(t, before+1, counter(lockResultError))
+	})
+}
+
+func TestLockResult(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	assert.Equal(t, lockResultSuccess, lockResult(context.Background(), nil))
+	assert.Equal(t, lockResultTimeout, lockResult(context.Background(), context.DeadlineExceeded))
+	assert.Equal(t, lockResultTimeout, lockResult(expired, errors.New("lock: failed to acquire")))
+	assert.Equal(t, lockResultCancelled, lockResult(context.Background(), fmt.Errorf("wrapped: %w", context.Canceled)))
+	assert.Equal(t, lockResultError, lockResult(context.Background(), errors.New("connection refused")))
+}
+
+func TestLockWaiters_RisesUnderContention(t *testing.T) {
+	locker := newMemoryLocker()
+	server := NewUserServerWithDB(&MockDB{}, locker)
+
+	// Hold the lock so every request below has to wait for it
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	defer unlock()
+
+	baseline := promtestutil.ToFloat64(lockWaiters)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	const waiters = 3
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+			assert.Error(t, err)
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return promtestutil.ToFloat64(lockWaiters) == baseline+waiters
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Abandon the waits; the gauge must drop back once they give up
+	cancel()
+	wg.Wait()
+	assert.Equal(t, baseline, promtestutil.ToFloat64(lockWaiters))
+}
+
+func TestUserCounters_IncrementOnCreateAndDelete(t *testing.T) {
+	createdBefore := promtestutil.ToFloat64(usersCreatedTotal)
+	deletedBefore := promtestutil.ToFloat64(usersDeletedTotal)
+
+	locker := &MockDistributedLocker{}
+	locker.On("LockUser", mock.Anything, mock.Anything).Return(func() {}, nil)
+	db := &MockDB{}
+	insertResult := &MockResult{}
+	insertResult.On("LastInsertId").Return(int64(1), nil)
+	db.On("ExecContext", mock.Anything, mock.MatchedBy(func(q string) bool { return strings.HasPrefix(q, "INSERT") }), mock.Anything).Return(insertResult, nil)
+	deleteResult := &MockResult{}
+	deleteResult.On("RowsAffected").Return(int64(1), nil).Once()
+	deleteResult.On("RowsAffected").Return(int64(0), nil)
+	db.On("ExecContext", mock.Anything, mock.MatchedBy(func(q string) bool { return strings.HasPrefix(q, "DELETE") }), mock.Anything).Return(deleteResult, nil)
+	server := NewUserServerWithDB(db, locker)
+
+	_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 28})
+	require.NoError(t, err)
+	_, err = server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1})
+	require.NoError(t, err)
+
+	// Deleting a missing user is not counted
+	resp, err := server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1})
+	require.NoError(t, err)
+	require.False(t, resp.Success)
+
+	assert.Equal(t, createdBefore+2, promtestutil.ToFloat64(usersCreatedTotal))
+	assert.Equal(t, deletedBefore+1, promtestutil.ToFloat64(usersDeletedTotal))
+}
+
+func TestRefreshUsersTotal(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	require.NoError(t, refreshUsersTotal(context.Background(), db))
+	assert.Equal(t, float64(42), promtestutil.ToFloat64(usersTotal))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestGetUserResultTotal(t *testing.T) {
+	columns := []string{"id", "name", "email", "age", "created_at", "updated_at"}
+	tests := []struct {
+		name   string
+		setup  func(sqlmock.Sqlmock)
+		result string
+	}{
+		{
+			name: "found",
+			setup: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+			},
+			result: getUserFound,
+		},
+		{
+			name: "not found",
+			setup: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows(columns))
+			},
+			result: getUserNotFound,
+		},
+		{
+			name: "database error",
+			setup: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(`SELECT`).WillReturnError(fmt.Errorf("connection reset"))
+			},
+			result: getUserError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, sqlMock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			tt.setup(sqlMock)
+
+			before := promtestutil.ToFloat64(getUserResultTotal.WithLabelValues(tt.result))
+			server := NewUserServerWithDB(db, newMemoryLocker())
+			_, _ = server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+
+			assert.Equal(t, before+1, promtestutil.ToFloat64(getUserResultTotal.WithLabelValues(tt.result)))
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestValidationErrorsTotal(t *testing.T) {
+	emptyName := validationErrorsTotal.WithLabelValues("name", "required")
+	badEmail := validationErrorsTotal.WithLabelValues("email", "invalid_format")
+	badAge := validationErrorsTotal.WithLabelValues("age", "out_of_range")
+	nameBefore, emailBefore, ageBefore := promtestutil.ToFloat64(emptyName), promtestutil.ToFloat64(badEmail), promtestutil.ToFloat64(badAge)
+
+	server := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+	_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:  "",
+		Email: "john.example.com",
+		Age:   30,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.Equal(t, nameBefore+1, promtestutil.ToFloat64(emptyName))
+	assert.Equal(t, emailBefore+1, promtestutil.ToFloat64(badEmail))
+	assert.Equal(t, ageBefore, promtestutil.ToFloat64(badAge), "valid age must not be counted")
+}
+
+func TestDBQueryDuration_ObservedPerOperation(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	operations := []string{dbOpGet, dbOpList, dbOpCreate, dbOpUpdate, dbOpDelete}
+	before := make(map[string]uint64)
+	for _, op := range operations {
+		if m := findMetric(t, "db_query_duration_seconds", map[string]string{"operation": op}); m != nil {
+			before[op] = m.GetHistogram().GetSampleCount()
+		}
+	}
+
+	sqlMock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	sqlMock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "Jane Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"))
+	sqlMock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := context.Background()
+	_, err = server.CreateUser(ctx, &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = server.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	_, err = server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	_, err = server.UpdateUser(ctx, &pb.UpdateUserRequest{Id: 1, Name: "Jane Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+	require.NoError(t, err)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+
+	for _, op := range operations {
+		m := findMetric(t, "db_query_duration_seconds", map[string]string{"operation": op})
+		require.NotNil(t, m, op)
+		assert.Equal(t, before[op]+1, m.GetHistogram().GetSampleCount(), op)
+	}
+}
+
+func TestLockHoldDuration_ReflectsSlowDB(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	var beforeCount uint64
+	var beforeSum float64
+	if m := findMetric(t, "lock_hold_duration_seconds", nil); m != nil {
+		beforeCount, beforeSum = m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+	}
+
+	const delay = 200 * time.Millisecond
+	sqlMock.ExpectExec(`DELETE FROM users`).WillDelayFor(delay).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1})
+	require.NoError(t, err)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+
+	m := findMetric(t, "lock_hold_duration_seconds", nil)
+	require.NotNil(t, m)
+	assert.Equal(t, beforeCount+1, m.GetHistogram().GetSampleCount())
+	held := m.GetHistogram().GetSampleSum() - beforeSum
+	assert.GreaterOrEqual(t, held, delay.Seconds())
+	assert.Less(t, held, (delay + time.Second).Seconds())
+}
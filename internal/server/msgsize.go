@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxRecvSizeByMethod caps the request size of individual methods, keyed by
+// method name, e.g. "CreateUser=4096,UpdateUsers=1048576"
+// (GRPC_MAX_RECV_MSG_SIZE_PER_METHOD). The largest limit also becomes gRPC's
+// server-wide receive limit, see recvSizeServerOptions; methods not listed are
+// only bounded by that.
+var maxRecvSizeByMethod map[string]int
+
+// parseMethodSizes parses a comma-separated list of Method=bytes pairs
+func parseMethodSizes(v string) (map[string]int, error) {
+	sizes := make(map[string]int)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		method, size, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not Method=bytes", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid size %q for %s", size, method)
+		}
+		sizes[strings.TrimSpace(method)] = n
+	}
+	return sizes, nil
+}
+
+// recvSizeServerOptions sets gRPC's server-wide receive limit to the largest
+// per-method limit. gRPC checks the length prefix of each message against it
+// and rejects larger requests with ResourceExhausted before reading or
+// decoding their body. Without per-method limits gRPC's default of 4MB
+// applies.
+func recvSizeServerOptions() []grpc.ServerOption {
+	largest := 0
+	for _, size := range maxRecvSizeByMethod {
+		largest = max(largest, size)
+	}
+	if largest == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.MaxRecvMsgSize(largest)}
+}
+
+// recvSizeLimitUnaryInterceptor is the finer check below the server-wide
+// limit: it rejects requests larger than their own method's limit with
+// ResourceExhausted before the handler runs. gRPC has already read and
+// decoded the request by then, so this protects the handler and the database,
+// not the decoder.
+func recvSizeLimitUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	if limit, ok := maxRecvSizeByMethod[method]; ok {
+		if msg, ok := req.(proto.Message); ok {
+			if size := proto.Size(msg); size > limit {
+				logger.WithFields(logrus.Fields{
+					"method": info.FullMethod,
+					"size":   size,
+					"limit":  limit,
+				}).Warn("Request exceeds per-method size limit")
+				return nil, status.Errorf(codes.ResourceExhausted, "request of %d bytes exceeds the %d byte limit of %s", size, limit, method)
+			}
+		}
+	}
+	return handler(ctx, req)
+}
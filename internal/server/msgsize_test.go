@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseMethodSizes(t *testing.T) {
+	got, err := parseMethodSizes(" CreateUser=4096, UpdateUsers = 1048576 ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"CreateUser": 4096, "UpdateUsers": 1048576}, got)
+
+	for _, v := range []string{"CreateUser", "CreateUser=big", "CreateUser=0"} {
+		_, err := parseMethodSizes(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestRecvSizeLimitUnaryInterceptor(t *testing.T) {
+	defer func(m map[string]int) { maxRecvSizeByMethod = m }(maxRecvSizeByMethod)
+	maxRecvSizeByMethod = map[string]int{"CreateUser": 1024, "UpdateUsers": 1 << 20}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(interceptorOptions(serverInterceptorStages())...)
+	pb.RegisterUserServiceServer(s, stubWriteServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewUserServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("oversized CreateUser is rejected", func(t *testing.T) {
+		_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Name: strings.Repeat("a", 2048), Email: "john@example.com", Age: 30})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("small CreateUser passes", func(t *testing.T) {
+		_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+		assert.NoError(t, err)
+	})
+
+	t.Run("large bulk update passes", func(t *testing.T) {
+		req := &pb.UpdateUsersRequest{}
+		for id := int32(1); id <= 500; id++ {
+			req.Users = append(req.Users, &pb.UpdateUserRequest{Id: id, Name: strings.Repeat("a", 200), Email: "john@example.com", Age: 30})
+		}
+		_, err := client.UpdateUsers(ctx, req)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRecvSizeServerOptions(t *testing.T) {
+	defer func(m map[string]int) { maxRecvSizeByMethod = m }(maxRecvSizeByMethod)
+
+	maxRecvSizeByMethod = nil
+	assert.Empty(t, recvSizeServerOptions())
+
+	maxRecvSizeByMethod = map[string]int{"CreateUser": 1024, "UpdateUsers": 4096}
+
+	// No interceptor: anything rejected here was rejected by gRPC itself,
+	// before the request was decoded
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(recvSizeServerOptions()...)
+	pb.RegisterUserServiceServer(s, stubWriteServer{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewUserServiceClient(conn)
+	ctx := context.Background()
+
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{Name: strings.Repeat("a", 8192), Email: "john@example.com", Age: 30})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "larger than max")
+
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{Name: strings.Repeat("a", 2048), Email: "john@example.com", Age: 30})
+	assert.NoError(t, err, "requests below the largest limit reach the per-method check")
+}
+
+// stubWriteServer accepts writes without touching a database
+type stubWriteServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (stubWriteServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	return &pb.CreateUserResponse{Success: true}, nil
+}
+
+func (stubWriteServer) UpdateUsers(ctx context.Context, req *pb.UpdateUsersRequest) (*pb.UpdateUsersResponse, error) {
+	return &pb.UpdateUsersResponse{Success: true}, nil
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	pb "go-grpc-server-client/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultMaxSendMsgSize matches the default receive limit of gRPC clients
+const defaultMaxSendMsgSize = 4 * 1024 * 1024
+
+// listResponseHeadroom is reserved in the ListUsers byte budget for the
+// response fields other than users (total, message, etag, token, ...)
+const listResponseHeadroom = 1024
+
+// maxSendMsgSize caps the size of a response message (GRPC_MAX_SEND_MSG_SIZE).
+// ListUsers stops adding users to a page before it would exceed it.
+var maxSendMsgSize = defaultMaxSendMsgSize
+
+// encodePageToken returns the opaque token continuing a listing after lastID
+func encodePageToken(lastID int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(int64(lastID), 10)))
+}
+
+// decodePageToken returns the id after which the listing continues
+func decodePageToken(token string) (int32, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid request: malformed page_token")
+	}
+	id, err := strconv.ParseInt(string(b), 10, 32)
+	if err != nil || id < 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid request: malformed page_token")
+	}
+	return int32(id), nil
+}
+
+// listUserSize is the number of bytes user adds to a ListUsersResponse
+func listUserSize(user *pb.User) int {
+	return protowire.SizeTag(1) + protowire.SizeBytes(proto.Size(user))
+}
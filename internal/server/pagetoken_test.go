@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserServer_ListUsers_ByteBudget(t *testing.T) {
+	defer func(n int) { maxSendMsgSize = n }(maxSendMsgSize)
+	maxSendMsgSize = 4096
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	longName := strings.Repeat("a", 255)
+	longEmail := strings.Repeat("b", 240) + "@example.com"
+	rows := sqlmock.NewRows(userColumns)
+	for id := 1; id <= 20; id++ {
+		rows.AddRow(id, longName, longEmail, 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	}
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+		WithArgs(20, 0).
+		WillReturnRows(rows)
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(20))
+
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 20})
+	require.NoError(t, err)
+
+	assert.Less(t, proto.Size(got), maxSendMsgSize)
+	require.NotEmpty(t, got.Users)
+	assert.Less(t, len(got.Users), 20)
+	require.NotEmpty(t, got.NextPageToken)
+
+	// The token continues right after the last returned user
+	last := got.Users[len(got.Users)-1].Id
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id > \? ORDER BY id ASC LIMIT \?`).
+		WithArgs(last, 20).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(last+1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+	next, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Limit: 20, PageToken: got.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, next.Users, 1)
+	assert.Equal(t, last+1, next.Users[0].Id)
+	assert.Empty(t, next.NextPageToken)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ListUsers_InvalidPageToken(t *testing.T) {
+	server := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+
+	_, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Limit: 10, PageToken: "not a token"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDecodePageToken_OutOfRange(t *testing.T) {
+	for _, raw := range []string{"2147483648", "-1", "99999999999999999999"} {
+		_, err := decodePageToken(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), raw)
+	}
+}
+
+func TestPageToken_RoundTrip(t *testing.T) {
+	for _, id := range []int32{0, 1, 12345, 1<<31 - 1} {
+		got, err := decodePageToken(encodePageToken(id))
+		require.NoError(t, err)
+		assert.Equal(t, id, got)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+
+	"go-grpc-server-client/internal/redact"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Payload log formats (LOG_PAYLOAD_FORMAT)
+const (
+	payloadFormatJSON = "json" // protojson, the default
+	payloadFormatText = "text" // protobuf text format
+)
+
+// logPayloads enables payloadLoggingUnaryInterceptor (LOG_PAYLOADS=on)
+var logPayloads bool
+
+// payloadFormat selects how payloads are rendered (LOG_PAYLOAD_FORMAT)
+var payloadFormat = payloadFormatJSON
+
+// payloadLoggingUnaryInterceptor logs every request and response message at
+// debug level. PII is redacted the same way as in the regular logs.
+func payloadLoggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !logger.IsLevelEnabled(logrus.DebugLevel) {
+		return handler(ctx, req)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"method":  info.FullMethod,
+		"request": formatPayload(req),
+	}).Debug("gRPC request payload")
+
+	resp, err := handler(ctx, req)
+
+	entry := logger.WithField("method", info.FullMethod)
+	if err != nil {
+		entry = entry.WithError(err)
+	} else {
+		entry = entry.WithField("response", formatPayload(resp))
+	}
+	entry.Debug("gRPC response payload")
+
+	return resp, err
+}
+
+// formatPayload renders a message in payloadFormat with PII redacted
+func formatPayload(v interface{}) string {
+	m, ok := v.(proto.Message)
+	if !ok || m == nil {
+		return ""
+	}
+
+	m = proto.Clone(m)
+	redactMessage(m.ProtoReflect())
+
+	var (
+		b   []byte
+		err error
+	)
+	if payloadFormat == payloadFormatText {
+		b, err = prototext.MarshalOptions{}.Marshal(m)
+	} else {
+		b, err = protojson.MarshalOptions{}.Marshal(m)
+	}
+	if err != nil {
+		return "<unprintable: " + err.Error() + ">"
+	}
+	return string(b)
+}
+
+// redactMessage masks every "email" field of m and its nested messages in place
+func redactMessage(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Kind() == protoreflect.StringKind && fd.Name() == "email" && !fd.IsList():
+			m.Set(fd, protoreflect.ValueOfString(redact.Email(v.String())))
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind && !fd.IsMap():
+			redactMessage(v.Message())
+		}
+		return true
+	})
+}
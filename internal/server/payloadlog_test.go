@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"go-grpc-server-client/internal/redact"
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestPayloadLoggingUnaryInterceptor_RedactsCreateUser(t *testing.T) {
+	defer redact.SetEnabled(redact.Enabled())
+	redact.SetEnabled(true)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(os.Stderr)
+	level := logger.GetLevel()
+	logger.SetLevel(logrus.DebugLevel)
+	defer logger.SetLevel(level)
+
+	req := &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.UserService/CreateUser"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		r := req.(*pb.CreateUserRequest)
+		return &pb.CreateUserResponse{User: &pb.User{Id: 1, Name: r.Name, Email: r.Email, Age: r.Age}, Success: true}, nil
+	}
+
+	resp, err := payloadLoggingUnaryInterceptor(context.Background(), req, info, handler)
+	require.NoError(t, err)
+
+	// The caller's messages are not modified by redaction
+	assert.Equal(t, "john@example.com", req.Email)
+	assert.Equal(t, "john@example.com", resp.(*pb.CreateUserResponse).User.Email)
+
+	entries := map[string]map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries[entry["msg"].(string)] = entry
+	}
+	assert.NotContains(t, buf.String(), "john@example.com")
+
+	reqEntry, ok := entries["gRPC request payload"]
+	require.True(t, ok)
+	assert.Equal(t, "debug", reqEntry["level"])
+	assert.Equal(t, info.FullMethod, reqEntry["method"])
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(reqEntry["request"].(string)), &body))
+	assert.Equal(t, "John Doe", body["name"])
+	assert.Equal(t, "j***@example.com", body["email"])
+
+	respEntry, ok := entries["gRPC response payload"]
+	require.True(t, ok)
+	assert.Contains(t, respEntry["response"], "j***@example.com")
+}
+
+func TestPayloadLoggingUnaryInterceptor_SkippedAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(os.Stderr)
+	level := logger.GetLevel()
+	logger.SetLevel(logrus.InfoLevel)
+	defer logger.SetLevel(level)
+
+	_, err := payloadLoggingUnaryInterceptor(context.Background(), &pb.GetUserRequest{Id: 1},
+		&grpc.UnaryServerInfo{FullMethod: "/service.UserService/GetUser"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return &pb.GetUserResponse{}, nil })
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
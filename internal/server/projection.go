@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	pb "go-grpc-server-client/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+)
+
+// userColumns lists the users table columns in pb.User field order
+var userColumns = []string{"id", "name", "email", "age", "created_at", "updated_at"}
+
+// userProjection returns the columns ListUsers selects for the requested
+// fields. No fields means every column; id is always included because
+// ordering and page tokens depend on it.
+func userProjection(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return userColumns, nil
+	}
+
+	want := map[string]bool{"id": true}
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if !slices.Contains(userColumns, f) {
+			return nil, invalidArgument([]*errdetails.BadRequest_FieldViolation{{
+				Field:       "fields",
+				Description: fmt.Sprintf("unknown field %q (must be one of %s)", f, strings.Join(userColumns, ", ")),
+			}})
+		}
+		want[f] = true
+	}
+
+	columns := make([]string, 0, len(want))
+	for _, c := range userColumns {
+		if want[c] {
+			columns = append(columns, c)
+		}
+	}
+	return columns, nil
+}
+
+// userScanTargets returns the pb.User fields matching columns, for rows.Scan
+func userScanTargets(user *pb.User, columns []string) []interface{} {
+	targets := make([]interface{}, len(columns))
+	for i, c := range columns {
+		switch c {
+		case "id":
+			targets[i] = &user.Id
+		case "name":
+			targets[i] = &user.Name
+		case "email":
+			targets[i] = &user.Email
+		case "age":
+			targets[i] = &user.Age
+		case "created_at":
+			targets[i] = &user.CreatedAt
+		case "updated_at":
+			targets[i] = &user.UpdatedAt
+		}
+	}
+	return targets
+}
+
+// projectedUsersETag hashes exactly what a projected page returns, for
+// projections that leave out updated_at
+func projectedUsersETag(users []*pb.User) string {
+	h := fnv.New64a()
+	opts := proto.MarshalOptions{Deterministic: true}
+	for _, u := range users {
+		b, _ := opts.Marshal(u)
+		h.Write(b)
+		h.Write([]byte{'\n'})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserServer_ListUsers_FieldsProjection(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT id, name FROM users ORDER BY id ASC LIMIT ? OFFSET ?`).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice").AddRow(2, "Bob"))
+	sqlMock.ExpectQuery(`SELECT COUNT(*) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10, Fields: []string{"name"}})
+
+	require.NoError(t, err)
+	require.Len(t, got.Users, 2)
+	assert.True(t, proto.Equal(&pb.User{Id: 1, Name: "Alice"}, got.Users[0]))
+	assert.True(t, proto.Equal(&pb.User{Id: 2, Name: "Bob"}, got.Users[1]))
+	assert.NotEmpty(t, got.Etag)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserProjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unspecified selects all", fields: nil, want: userColumns},
+		{name: "id always included", fields: []string{"name"}, want: []string{"id", "name"}},
+		{name: "table order and case-insensitive", fields: []string{"UPDATED_AT", "email", "id"}, want: []string{"id", "email", "updated_at"}},
+		{name: "unknown field", fields: []string{"password"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userProjection(tt.fields)
+			if tt.wantErr {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
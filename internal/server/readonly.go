@@ -0,0 +1,19 @@
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readOnlyMode rejects every write RPC while reads keep working, e.g. during
+// maintenance (READ_ONLY_MODE=on)
+var readOnlyMode bool
+
+// checkWritable fails write handlers with FailedPrecondition in read-only mode
+func checkWritable(method string) error {
+	if readOnlyMode {
+		logger.WithField("method", method).Warn("Write rejected in read-only mode")
+		return status.Error(codes.FailedPrecondition, "service in read-only mode")
+	}
+	return nil
+}
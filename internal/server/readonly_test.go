@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserServer_ReadOnlyMode(t *testing.T) {
+	defer func(v bool) { readOnlyMode = v }(readOnlyMode)
+	readOnlyMode = true
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+	ctx := context.Background()
+
+	t.Run("writes are rejected", func(t *testing.T) {
+		_, err := server.CreateUser(ctx, &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+		assertReadOnly(t, err)
+
+		_, err = server.UpdateUser(ctx, &pb.UpdateUserRequest{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30})
+		assertReadOnly(t, err)
+
+		_, err = server.UpdateUsers(ctx, &pb.UpdateUsersRequest{Users: []*pb.UpdateUserRequest{{Id: 1, Name: "John Doe", Email: "john@example.com", Age: 30}}})
+		assertReadOnly(t, err)
+
+		_, err = server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+		assertReadOnly(t, err)
+	})
+
+	t.Run("reads are allowed", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+		got, err := server.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), got.User.Id)
+
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		list, err := server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, list.Users, 1)
+	})
+
+	// No write ever reached the database
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func assertReadOnly(t *testing.T, err error) {
+	t.Helper()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Contains(t, err.Error(), "service in read-only mode")
+}
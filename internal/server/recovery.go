@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryUnaryInterceptor turns a panic in the rest of the chain or the
+// handler into an Internal error instead of crashing the process
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor is recoveryUnaryInterceptor for streams
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// recoveredError logs a recovered panic with its stack and returns the error
+// sent to the client, which does not reveal the panic value
+func recoveredError(method string, r interface{}) error {
+	logger.WithField("method", method).WithField("panic", r).WithField("stack", string(debug.Stack())).Error("Recovered from panic in gRPC handler")
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+type lockScopeKey struct{}
+
+// lockScope records the user locks held by one request. It travels in the
+// request context so that nested handler calls (e.g. a bulk operation reusing
+// single-user logic) see locks taken further up the call chain.
+type lockScope struct {
+	mu   sync.Mutex
+	held map[int32]bool
+}
+
+// withLockScope returns ctx carrying a lock scope, reusing an existing one
+func withLockScope(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(lockScopeKey{}).(*lockScope); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, lockScopeKey{}, &lockScope{held: make(map[int32]bool)})
+}
+
+// lockScopeUnaryInterceptor gives every RPC its own lock scope
+func lockScopeUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withLockScope(ctx), req)
+}
+
+// reentrantLocker makes a DistributedLocker reentrant within a lock scope:
+// locking a user whose lock the scope already holds returns a no-op unlock
+// instead of deadlocking on the non-reentrant backend lock. Only the
+// outermost acquisition releases the lock. Without a scope in the context it
+// behaves exactly like the wrapped locker.
+type reentrantLocker struct {
+	DistributedLocker
+}
+
+func (l *reentrantLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
+	scope, ok := ctx.Value(lockScopeKey{}).(*lockScope)
+	if !ok {
+		return l.DistributedLocker.LockUser(ctx, userID)
+	}
+
+	scope.mu.Lock()
+	held := scope.held[userID]
+	scope.mu.Unlock()
+	if held {
+		logger.WithField("user_id", userID).Debug("Lock already held by this request, reusing it")
+		return func() {}, nil
+	}
+
+	unlock, err := l.DistributedLocker.LockUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope.mu.Lock()
+	scope.held[userID] = true
+	scope.mu.Unlock()
+
+	return func() {
+		scope.mu.Lock()
+		delete(scope.held, userID)
+		scope.mu.Unlock()
+		unlock()
+	}, nil
+}
+
+func (l *reentrantLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	if _, ok := ctx.Value(lockScopeKey{}).(*lockScope); !ok {
+		return l.DistributedLocker.LockUsers(ctx, userIDs)
+	}
+	return lockUsersInOrder(ctx, l.LockUser, userIDs)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReentrantLocker_NestedCallSameUser(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectExec(`UPDATE users SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Updated", "john@example.com", 31, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"))
+
+	backend := newMemoryLocker()
+	server := NewUserServerWithDB(db, backend)
+	ctx := withLockScope(context.Background())
+
+	// An outer operation holds user 1 and then reuses the single-user handler
+	unlock, err := server.lockUser(ctx, 1)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := server.UpdateUser(ctx, &pb.UpdateUserRequest{Id: 1, Name: "John Updated", Email: "john@example.com", Age: 31})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("nested call on the same user deadlocked")
+	}
+
+	// The nested unlock is a no-op: the outer lock is still held
+	assert.Equal(t, []int32{1}, backend.acquired)
+	lockCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = backend.LockUser(lockCtx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Releasing the outer lock frees it for other requests
+	unlock()
+	other, err := server.lockUser(withLockScope(context.Background()), 1)
+	require.NoError(t, err)
+	other()
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReentrantLocker_LockUsersSkipsHeld(t *testing.T) {
+	backend := newMemoryLocker()
+	locker := &reentrantLocker{backend}
+	ctx := withLockScope(context.Background())
+
+	unlockOne, err := locker.LockUser(ctx, 2)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		unlockAll, err := locker.LockUsers(ctx, []int32{3, 2, 1})
+		if assert.NoError(t, err) {
+			unlockAll()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("bulk lock including a held user deadlocked")
+	}
+	assert.Equal(t, []int32{2, 1, 3}, backend.acquired)
+	unlockOne()
+}
+
+func TestReentrantLocker_SeparateScopesStillExclude(t *testing.T) {
+	locker := &reentrantLocker{newMemoryLocker()}
+
+	unlock, err := locker.LockUser(withLockScope(context.Background()), 1)
+	require.NoError(t, err)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(withLockScope(context.Background()), 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.LockUser(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// openReplicas opens the comma-separated MYSQL_READ_DSN replicas. A replica
+// that cannot be reached at startup is still used: reads fall back to the
+// primary whenever it fails.
+func openReplicas(readDSNs string) ([]DBInterface, error) {
+	var replicas []DBInterface
+	for _, raw := range strings.Split(readDSNs, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		dsn, err := buildDSN(raw, dbStatementTimeout)
+		if err != nil {
+			closeDBs(replicas)
+			return nil, fmt.Errorf("failed to parse MYSQL_READ_DSN %s: %w", maskDSN(raw), err)
+		}
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			closeDBs(replicas)
+			return nil, fmt.Errorf("failed to open MySQL read replica %s: %w", maskDSN(raw), err)
+		}
+		if err := db.Ping(); err != nil {
+			logger.WithError(err).WithField("mysql_read_dsn", maskDSN(raw)).Warn("MySQL read replica is not reachable, reads will fall back to the primary")
+		}
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
+// closeDBs closes the given databases, logging rather than returning
+// failures as it runs at shutdown
+func closeDBs(dbs []DBInterface) {
+	for _, db := range dbs {
+		if c, ok := db.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				logger.WithError(err).Warn("Failed to close MySQL connection")
+			}
+		}
+	}
+}
+
+// close releases the server's primary and read replica connections once it
+// has stopped serving
+func (s *UserServer) close() {
+	closeDBs(append([]DBInterface{s.db}, s.replicas...))
+}
+
+// readDB returns the next read replica in round-robin order, or the primary
+// when no replica is configured
+func (s *UserServer) readDB() DBInterface {
+	if len(s.replicas) == 0 {
+		return s.db
+	}
+	n := atomic.AddUint64(&s.nextReplica, 1)
+	return s.replicas[(n-1)%uint64(len(s.replicas))]
+}
+
+// queryRead runs a read-only query on a replica, retrying on the primary if
+// the replica fails
+func (s *UserServer) queryRead(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, _, err := s.queryReadFrom(ctx, query, args...)
+	return rows, err
+}
+
+// queryReadFrom is queryRead also returning the database that answered
+func (s *UserServer) queryReadFrom(ctx context.Context, query string, args ...interface{}) (*sql.Rows, DBInterface, error) {
+	db := s.readDB()
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err == nil || db == s.db || ctx.Err() != nil {
+		return rows, db, err
+	}
+	requestLog(ctx).WithError(err).Warn("Read replica query failed, falling back to primary")
+	rows, err = s.db.QueryContext(ctx, query, args...)
+	return rows, s.db, err
+}
+
+// queryRowRead is the single-row counterpart of queryRead. scan receives the
+// row and its error decides whether to fall back; sql.ErrNoRows is a valid
+// answer and is returned as is.
+func (s *UserServer) queryRowRead(ctx context.Context, scan func(*sql.Row) error, query string, args ...interface{}) error {
+	db := s.readDB()
+	err := scan(db.QueryRowContext(ctx, query, args...))
+	if err == nil || err == sql.ErrNoRows || db == s.db || ctx.Err() != nil {
+		return err
+	}
+	requestLog(ctx).WithError(err).Warn("Read replica query failed, falling back to primary")
+	return scan(s.db.QueryRowContext(ctx, query, args...))
+}
+
+// Values of the served_from field of ListUsersResponse
+const (
+	servedFromPrimary = "primary"
+	servedFromReplica = "replica"
+)
+
+// replicaLagTTL is how long a replica's lag reading is reused, so that
+// ListUsers does not run a status query on the replica for every page
+const replicaLagTTL = 5 * time.Second
+
+// replicaLagCache holds the last lag reading of each replica
+type replicaLagCache struct {
+	mu      sync.Mutex
+	samples map[DBInterface]replicaLagSample
+}
+
+type replicaLagSample struct {
+	lag int64
+	at  time.Time
+}
+
+// get returns the lag of replica, reading it again once the cached value is
+// older than replicaLagTTL
+func (c *replicaLagCache) get(ctx context.Context, replica DBInterface) int64 {
+	c.mu.Lock()
+	sample, ok := c.samples[replica]
+	c.mu.Unlock()
+	if ok && time.Since(sample.at) < replicaLagTTL {
+		return sample.lag
+	}
+
+	lag := replicaLag(ctx, replica)
+	c.mu.Lock()
+	if c.samples == nil {
+		c.samples = make(map[DBInterface]replicaLagSample)
+	}
+	c.samples[replica] = replicaLagSample{lag: lag, at: time.Now()}
+	c.mu.Unlock()
+	return lag
+}
+
+// replicaLag reports how many seconds replica is behind its source, from
+// Seconds_Behind_Source of SHOW REPLICA STATUS (MySQL 8.0.22+), falling back
+// to Seconds_Behind_Master of SHOW SLAVE STATUS on older servers. It returns
+// -1 when the lag is unknown: the status cannot be read (it needs the
+// REPLICATION CLIENT privilege), the server is not a replica, or replication
+// is stopped.
+func replicaLag(ctx context.Context, replica DBInterface) int64 {
+	lag, err := readReplicaLag(ctx, replica, `SHOW REPLICA STATUS`, "Seconds_Behind_Source")
+	if err != nil {
+		logger.WithError(err).Debug("SHOW REPLICA STATUS failed, trying SHOW SLAVE STATUS")
+		if lag, err = readReplicaLag(ctx, replica, `SHOW SLAVE STATUS`, "Seconds_Behind_Master"); err != nil {
+			logger.WithError(err).Debug("Failed to read replica status")
+		}
+	}
+	return lag
+}
+
+// readReplicaLag runs a replica status query and parses column from it. err
+// is set only when the query itself fails.
+func readReplicaLag(ctx context.Context, replica DBInterface, query, column string) (int64, error) {
+	rows, err := replica.QueryContext(ctx, query)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil || !rows.Next() {
+		return -1, nil
+	}
+	values := make([]sql.NullString, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := rows.Scan(targets...); err != nil {
+		logger.WithError(err).Debug("Failed to scan replica status")
+		return -1, nil
+	}
+	for i, c := range columns {
+		if c != column {
+			continue
+		}
+		lag, err := strconv.ParseInt(values[i].String, 10, 64)
+		if !values[i].Valid || err != nil {
+			return -1, nil
+		}
+		return lag, nil
+	}
+	return -1, nil
+}
+
+// readFreshness describes the database that served a read for the
+// served_from and replica_lag_seconds response fields
+func (s *UserServer) readFreshness(ctx context.Context, db DBInterface) (servedFrom string, lagSeconds int64) {
+	if db == s.db {
+		return servedFromPrimary, 0
+	}
+	return servedFromReplica, s.replicaLags.get(ctx, db)
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserServer_ReadReplicaRouting(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	// Reads go to the replica
+	replicaMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	replicaMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+	// Writes go to the primary
+	primaryMock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(2, 1))
+	primaryMock.ExpectExec(`DELETE FROM users`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	got, err := server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	assert.True(t, got.Success)
+
+	list, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list.Users, 1)
+
+	_, err = server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 28})
+	require.NoError(t, err)
+	_, err = server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 2})
+	require.NoError(t, err)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestUserServer_GetUser_ConfirmTokenFromPrimary(t *testing.T) {
+	defer func(v bool) { requireDeleteConfirm = v }(requireDeleteConfirm)
+	requireDeleteConfirm = true
+
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	// The replica lags behind an edit already on the primary; GetUser must
+	// not read it, or the token would be stale from the start
+	replicaMock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	primaryMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "jane@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"))
+	primaryMock.ExpectQuery(`SELECT name, email, age, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "email", "age", "updated_at"}).
+			AddRow("John Doe", "jane@example.com", 30, "2023-01-02T00:00:00Z"))
+	primaryMock.ExpectExec(`DELETE FROM users`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	got, err := server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", got.User.Email)
+
+	deleted, err := server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1, ConfirmToken: got.ConfirmToken})
+	require.NoError(t, err)
+	assert.True(t, deleted.Success)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	// The lagging row was never read
+	assert.Error(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ReadReplicaRoundRobin(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+
+	var replicas []DBInterface
+	var mocks []sqlmock.Sqlmock
+	for i := 0; i < 2; i++ {
+		replica, replicaMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer replica.Close()
+		replicas = append(replicas, replica)
+		mocks = append(mocks, replicaMock)
+	}
+
+	server := NewUserServerWithReplicas(primary, replicas, newMemoryLocker())
+
+	for i := 0; i < 4; i++ {
+		mocks[i%2].ExpectQuery(`SELECT`).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	}
+	for i := 0; i < 4; i++ {
+		_, err := server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+		require.NoError(t, err)
+	}
+
+	for _, m := range mocks {
+		assert.NoError(t, m.ExpectationsWereMet())
+	}
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ReadReplicaFallback(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	replicaMock.ExpectQuery(`SELECT`).WillReturnError(fmt.Errorf("replica down"))
+	primaryMock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	replicaMock.ExpectQuery(`SELECT`).WillReturnError(fmt.Errorf("replica down"))
+	primaryMock.ExpectQuery(`SELECT`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+	got, err := server.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", got.User.Name)
+
+	list, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list.Users, 1)
+
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ListUsers_ReplicaFreshness(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	replicaMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).
+		WillReturnRows(sqlmock.NewRows([]string{"Replica_IO_State", "Source_Host", "Seconds_Behind_Source"}).
+			AddRow("Waiting for source to send event", "primary", "7"))
+	replicaMock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, servedFromReplica, got.ServedFrom)
+	assert.Equal(t, int64(7), got.ReplicaLagSeconds)
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ListUsers_ServedFromPrimary(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	// The replica fails, so the page comes from the primary and is current
+	replicaMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC`).
+		WillReturnError(fmt.Errorf("replica down"))
+	primaryMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, servedFromPrimary, got.ServedFrom)
+	assert.Equal(t, int64(0), got.ReplicaLagSeconds)
+}
+
+func TestReplicaLag_Unknown(t *testing.T) {
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	// Replication stopped: Seconds_Behind_Source is NULL
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).
+		WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}).AddRow(nil))
+	assert.Equal(t, int64(-1), replicaLag(context.Background(), replica))
+
+	// Not a replica
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).
+		WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}))
+	assert.Equal(t, int64(-1), replicaLag(context.Background(), replica))
+
+	// Neither statement can be read
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).WillReturnError(fmt.Errorf("access denied"))
+	replicaMock.ExpectQuery(`SHOW SLAVE STATUS`).WillReturnError(fmt.Errorf("access denied"))
+	assert.Equal(t, int64(-1), replicaLag(context.Background(), replica))
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicaLag_FallsBackToSlaveStatus(t *testing.T) {
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	// MySQL before 8.0.22 does not know SHOW REPLICA STATUS
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).WillReturnError(fmt.Errorf("Error 1064: You have an error in your SQL syntax"))
+	replicaMock.ExpectQuery(`SHOW SLAVE STATUS`).
+		WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Master"}).AddRow("3"))
+
+	assert.Equal(t, int64(3), replicaLag(context.Background(), replica))
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicaLagCache_ReusesRecentReading(t *testing.T) {
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	// Only one status query: the second reading comes from the cache
+	replicaMock.ExpectQuery(`SHOW REPLICA STATUS`).
+		WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Source"}).AddRow("2"))
+
+	var cache replicaLagCache
+	assert.Equal(t, int64(2), cache.get(context.Background(), replica))
+	assert.Equal(t, int64(2), cache.get(context.Background(), replica))
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestUserServer_CloseClosesReplicas(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	server := NewUserServerWithReplicas(primary, []DBInterface{replica}, newMemoryLocker())
+
+	primaryMock.ExpectClose()
+	replicaMock.ExpectClose()
+	server.close()
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
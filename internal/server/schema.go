@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// checkSchemaHealth adds the users schema check to /healthz (HEALTHCHECK_SCHEMA)
+var checkSchemaHealth bool
+
+// schemaQuerier is the part of *sql.DB the schema check uses
+type schemaQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// checkUsersSchema verifies via information_schema that the users table
+// exists in the current database with every column the handlers use and a
+// unique index on email
+func checkUsersSchema(ctx context.Context, db schemaQuerier) error {
+	rows, err := db.QueryContext(ctx,
+		`SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users'`)
+	if err != nil {
+		return fmt.Errorf("failed to query users schema: %w", err)
+	}
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to read users schema: %w", err)
+		}
+		present[strings.ToLower(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read users schema: %w", err)
+	}
+
+	if len(present) == 0 {
+		return fmt.Errorf("users table not found")
+	}
+	var missing []string
+	for _, c := range userColumns {
+		if !present[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("users table is missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	hasIndex, err := hasEmailUniqueIndex(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !hasIndex {
+		return fmt.Errorf("users.email has no unique index")
+	}
+	return nil
+}
+
+// hasEmailUniqueIndex reports whether users has a unique index on email
+// alone. CreateUser relies on it to reject duplicate emails.
+func hasEmailUniqueIndex(ctx context.Context, db schemaQuerier) (bool, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'users' AND non_unique = 0 GROUP BY index_name HAVING COUNT(*) = 1 AND MAX(column_name) = 'email'`)
+	if err != nil {
+		return false, fmt.Errorf("failed to query users indexes: %w", err)
+	}
+	defer rows.Close()
+
+	found := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to read users indexes: %w", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaQuery = `SELECT column_name FROM information_schema.columns`
+
+const emailIndexQuery = `SELECT index_name FROM information_schema.statistics`
+
+// emailIndex returns the index lookup result for a users table with or
+// without the unique index on email
+func emailIndex(present bool) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"index_name"})
+	if present {
+		rows.AddRow("email")
+	}
+	return rows
+}
+
+func TestCheckUsersSchema(t *testing.T) {
+	t.Run("all columns present", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"column_name"})
+		for _, c := range userColumns {
+			rows.AddRow(c)
+		}
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(rows)
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+
+		assert.NoError(t, checkUsersSchema(context.Background(), db))
+	})
+
+	t.Run("missing email unique index", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"column_name"})
+		for _, c := range userColumns {
+			rows.AddRow(c)
+		}
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(rows)
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(false))
+
+		assert.EqualError(t, checkUsersSchema(context.Background(), db), "users.email has no unique index")
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(schemaQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("name").AddRow("email").AddRow("age"))
+
+		err = checkUsersSchema(context.Background(), db)
+		assert.EqualError(t, err, "users table is missing columns: created_at, updated_at")
+	})
+}
+
+func TestPrepareSchema(t *testing.T) {
+	defer func(v bool) { dbAutoMigrate = v }(dbAutoMigrate)
+
+	allColumns := func() *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"column_name"})
+		for _, c := range userColumns {
+			rows.AddRow(c)
+		}
+		return rows
+	}
+
+	t.Run("auto migrate on", func(t *testing.T) {
+		dbAutoMigrate = true
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectExec(`CREATE TABLE IF NOT EXISTS users`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(allColumns())
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+
+		require.NoError(t, prepareSchema(db))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("auto migrate adds missing email index", func(t *testing.T) {
+		dbAutoMigrate = true
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectExec(`CREATE TABLE IF NOT EXISTS users`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(false))
+		sqlMock.ExpectExec(`ALTER TABLE users ADD UNIQUE INDEX uniq_users_email \(email\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(allColumns())
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+
+		require.NoError(t, prepareSchema(db))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("auto migrate fails on duplicate emails", func(t *testing.T) {
+		dbAutoMigrate = true
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectExec(`CREATE TABLE IF NOT EXISTS users`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(false))
+		sqlMock.ExpectExec(`ALTER TABLE users ADD UNIQUE INDEX`).WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry})
+
+		assert.ErrorContains(t, prepareSchema(db), "remove duplicate emails first")
+	})
+
+	t.Run("auto migrate off", func(t *testing.T) {
+		dbAutoMigrate = false
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		// No CREATE TABLE is expected: sqlmock fails any unexpected statement
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(allColumns())
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+
+		require.NoError(t, prepareSchema(db))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("auto migrate off with missing table", func(t *testing.T) {
+		dbAutoMigrate = false
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(sqlmock.NewRows([]string{"column_name"}))
+
+		assert.ErrorContains(t, prepareSchema(db), "users table not found")
+	})
+}
+
+func TestHealthz_SchemaMissingTable(t *testing.T) {
+	defer func(db healthPinger, on bool) { mainDB, checkSchemaHealth = db, on }(mainDB, checkSchemaHealth)
+
+	db, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	mainDB = db
+	checkSchemaHealth = true
+
+	sqlMock.ExpectPing()
+	sqlMock.ExpectQuery(schemaQuery).WillReturnRows(sqlmock.NewRows([]string{"column_name"}))
+
+	rec := httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "schema error: users table not found", rec.Body.String())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// selfCheckTimeout bounds each self-check step
+const selfCheckTimeout = 5 * time.Second
+
+// selfCheckDB is the part of *sql.DB the self-check uses
+type selfCheckDB interface {
+	healthPinger
+	schemaQuerier
+}
+
+// selfCheckReport collects step results and prints them as they complete
+type selfCheckReport struct {
+	w      io.Writer
+	failed []string
+}
+
+func (r *selfCheckReport) ok(step, detail string) {
+	fmt.Fprintf(r.w, "[ OK ] %-8s %s\n", step, detail)
+}
+
+func (r *selfCheckReport) fail(step string, err error) {
+	r.failed = append(r.failed, step)
+	fmt.Fprintf(r.w, "[FAIL] %-8s %v\n", step, err)
+}
+
+func (r *selfCheckReport) skip(step, reason string) {
+	fmt.Fprintf(r.w, "[SKIP] %-8s %s\n", step, reason)
+}
+
+func (r *selfCheckReport) err() error {
+	if len(r.failed) == 0 {
+		fmt.Fprintln(r.w, "self-check passed")
+		return nil
+	}
+	err := fmt.Errorf("self-check failed: %s", strings.Join(r.failed, ", "))
+	fmt.Fprintln(r.w, err)
+	return err
+}
+
+// SelfCheck validates the server configuration from the environment without
+// serving traffic: it connects to MySQL and the distributed locker, verifies
+// the users table, and writes a report to w. It returns an error if any step
+// failed.
+func SelfCheck(w io.Writer) error {
+	mysqlDSN := os.Getenv("MYSQL_DSN")
+	lockType := os.Getenv("LOCK_TYPE")
+	redisAddr := os.Getenv("REDIS_ADDR")
+	etcdEndpoints := os.Getenv("ETCD_ENDPOINTS")
+
+	report := &selfCheckReport{w: w}
+	if err := validateServerConfig(mysqlDSN, lockType, redisAddr, etcdEndpoints); err != nil {
+		report.fail("config", err)
+		return report.err()
+	}
+	report.ok("config", fmt.Sprintf("lock_type=%s mysql_dsn=%s", strings.ToLower(lockType), maskDSN(mysqlDSN)))
+
+	var db selfCheckDB
+	dsn, err := buildDSN(mysqlDSN, dbStatementTimeout)
+	if err == nil {
+		var sqlDB *sql.DB
+		if sqlDB, err = sql.Open("mysql", dsn); err == nil {
+			defer sqlDB.Close()
+			db = sqlDB
+		}
+	}
+	if err != nil {
+		report.fail("mysql", err)
+	}
+
+	var locker DistributedLocker
+	if l, err := openLocker(lockType, redisAddr, etcdEndpoints); err != nil {
+		report.fail("locker", err)
+	} else {
+		locker = l
+		defer closeLocker(locker)
+	}
+
+	return runSelfCheck(context.Background(), report, db, locker)
+}
+
+// runSelfCheck checks the connections SelfCheck opened; a nil db or locker
+// has already been reported as failed
+func runSelfCheck(ctx context.Context, report *selfCheckReport, db selfCheckDB, locker DistributedLocker) error {
+	if db != nil {
+		pingCtx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err != nil {
+			report.fail("mysql", fmt.Errorf("ping failed: %w", err))
+			report.skip("schema", "MySQL unreachable")
+		} else {
+			report.ok("mysql", "reachable")
+
+			schemaCtx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+			if err := checkUsersSchema(schemaCtx, db); err == nil {
+				report.ok("schema", "users columns and email unique index present")
+			} else {
+				report.fail("schema", err)
+			}
+			cancel()
+		}
+	} else {
+		report.skip("schema", "MySQL unreachable")
+	}
+
+	if locker != nil {
+		lockCtx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+		err := locker.HealthCheck(lockCtx)
+		cancel()
+		if err != nil {
+			report.fail("locker", err)
+		} else {
+			report.ok("locker", "reachable")
+		}
+	}
+
+	return report.err()
+}
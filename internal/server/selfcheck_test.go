@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSelfCheck(t *testing.T) {
+	t.Run("all checks pass", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		defer db.Close()
+		sqlMock.ExpectPing()
+		columns := sqlmock.NewRows([]string{"column_name"})
+		for _, c := range userColumns {
+			columns.AddRow(c)
+		}
+		sqlMock.ExpectQuery(schemaQuery).WillReturnRows(columns)
+		sqlMock.ExpectQuery(emailIndexQuery).WillReturnRows(emailIndex(true))
+
+		var out bytes.Buffer
+		err = runSelfCheck(context.Background(), &selfCheckReport{w: &out}, db, newMemoryLocker())
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "self-check passed")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		defer db.Close()
+		sqlMock.ExpectPing()
+		sqlMock.ExpectQuery(schemaQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("name").AddRow("email").AddRow("age"))
+
+		var out bytes.Buffer
+		err = runSelfCheck(context.Background(), &selfCheckReport{w: &out}, db, newMemoryLocker())
+
+		require.Error(t, err)
+		assert.Contains(t, out.String(), "[FAIL] schema   users table is missing columns: created_at, updated_at")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("database ping fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		defer db.Close()
+		sqlMock.ExpectPing().WillReturnError(fmt.Errorf("connection refused"))
+
+		var out bytes.Buffer
+		err = runSelfCheck(context.Background(), &selfCheckReport{w: &out}, db, newMemoryLocker())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mysql")
+		assert.Contains(t, out.String(), "[FAIL] mysql")
+		assert.Contains(t, out.String(), "[SKIP] schema")
+		assert.Contains(t, out.String(), "[ OK ] locker")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestSelfCheck_InvalidConfig(t *testing.T) {
+	t.Setenv("MYSQL_DSN", "")
+
+	var out bytes.Buffer
+	err := SelfCheck(&out)
+
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "[FAIL] config")
+}
@@ -3,13 +3,24 @@ package server
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"net"
-	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	_ "go-grpc-server-client/internal/config" // load CONFIG_FILE before init reads settings
+	"go-grpc-server-client/internal/redact"
+	"go-grpc-server-client/internal/tlsversion"
 	pb "go-grpc-server-client/proto"
 
 	redis "github.com/go-redis/redis/v8"
@@ -17,22 +28,71 @@ import (
 	redsyncredis "github.com/go-redsync/redsync/v4/redis/goredis/v8"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor for clients that request it
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	concurrency "go.etcd.io/etcd/client/v3/concurrency"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Pagination defaults for ListUsers
+const (
+	defaultPageLimit int32 = 100
+	pageLimitHeader        = "x-page-limit" // response header carrying the effective limit
 )
 
 var (
 	logger              = logrus.New()
-	mainDB              *sql.DB           // for health check
+	mainDB              healthPinger      // for health check
 	checkExternalHealth bool              // for health check option
 	globalLocker        DistributedLocker // for health check
 )
 
+// maxPageLimit is the upper bound for the ListUsers limit (MAX_PAGE_LIMIT)
+var maxPageLimit int32 = 500
+
+// dbAutoMigrate creates the users table at startup; turn it off
+// (DB_AUTO_MIGRATE=off) where the app user lacks DDL privileges and
+// migrations are run externally
+var dbAutoMigrate = true
+
+// dbStatementTimeout is enforced by MySQL via max_execution_time (DB_STATEMENT_TIMEOUT)
+var dbStatementTimeout time.Duration
+
+// lockKeyPrefix namespaces lock keys so that deployments sharing a Redis/etcd
+// cluster don't contend on each other's locks (LOCK_KEY_PREFIX)
+var lockKeyPrefix string
+
+// etcdSessionTTL is the lease TTL of etcd lock sessions, i.e. how long a lock
+// outlives a crashed holder (ETCD_SESSION_TTL). Zero keeps etcd's default of 60s.
+var etcdSessionTTL time.Duration
+
+// Lock circuit breaker: opens after lockBreakerFailures consecutive lock
+// failures (LOCK_BREAKER_FAILURES, 0 disables) and fails fast for
+// lockBreakerTimeout (LOCK_BREAKER_TIMEOUT) before probing the backend again
+var (
+	lockBreakerFailures uint32 = 5
+	lockBreakerTimeout         = 30 * time.Second
+)
+
+// Redis lock retries: how many times LockUser tries to take a contended lock
+// (LOCK_RETRIES) and how long it waits between tries (LOCK_RETRY_DELAY).
+// Zero keeps redsync's defaults.
+var (
+	lockRetries    int
+	lockRetryDelay time.Duration
+)
+
 func init() {
 	// Configure logrus
 	logger.SetFormatter(&logrus.JSONFormatter{
@@ -56,6 +116,256 @@ func init() {
 	if v := os.Getenv("HEALTHCHECK_EXTERNAL"); strings.ToLower(v) == "on" {
 		checkExternalHealth = true
 	}
+	if v := os.Getenv("HEALTHCHECK_SCHEMA"); strings.ToLower(v) == "on" {
+		checkSchemaHealth = true
+	}
+	if v := os.Getenv("HEALTHCHECK_DB_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 && d < healthWriteTimeout {
+			healthDBPingTimeout = d
+		} else {
+			logger.WithField("healthcheck_db_timeout", v).Warn("Ignoring invalid HEALTHCHECK_DB_TIMEOUT (must be positive and below the 10s write timeout)")
+		}
+	}
+
+	// Profiling endpoints on the metrics server
+	if v := os.Getenv("PPROF_ENABLED"); strings.ToLower(v) == "on" {
+		pprofEnabled = true
+	}
+
+	// Read-only mode: reject writes, e.g. during maintenance
+	if v := os.Getenv("READ_ONLY_MODE"); strings.ToLower(v) == "on" {
+		readOnlyMode = true
+		logger.Warn("READ_ONLY_MODE enabled: create/update/delete are rejected")
+	}
+
+	// ListUsers page size cap
+	if v := os.Getenv("MAX_PAGE_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxPageLimit = int32(n)
+		} else {
+			logger.WithField("max_page_limit", v).Warn("Ignoring invalid MAX_PAGE_LIMIT")
+		}
+	}
+
+	lockKeyPrefix = os.Getenv("LOCK_KEY_PREFIX")
+
+	// MySQL server-side statement timeout, e.g. "5s"
+	if v := os.Getenv("DB_STATEMENT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			dbStatementTimeout = d
+		} else {
+			logger.WithField("db_statement_timeout", v).Warn("Ignoring invalid DB_STATEMENT_TIMEOUT")
+		}
+	}
+
+	if v := os.Getenv("ETCD_SESSION_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Second {
+			etcdSessionTTL = d
+		} else {
+			logger.WithField("etcd_session_ttl", v).Warn("Ignoring invalid ETCD_SESSION_TTL (must be at least 1s)")
+		}
+	}
+	if v := os.Getenv("ETCD_SESSION_REUSE"); strings.ToLower(v) == "on" {
+		etcdSessionReuse = true
+	}
+
+	if v := os.Getenv("LOCK_BREAKER_FAILURES"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			lockBreakerFailures = uint32(n)
+		} else {
+			logger.WithField("lock_breaker_failures", v).Warn("Ignoring invalid LOCK_BREAKER_FAILURES")
+		}
+	}
+	if v := os.Getenv("LOCK_BREAKER_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			lockBreakerTimeout = d
+		} else {
+			logger.WithField("lock_breaker_timeout", v).Warn("Ignoring invalid LOCK_BREAKER_TIMEOUT")
+		}
+	}
+	if v := os.Getenv("DB_AUTO_MIGRATE"); strings.ToLower(v) == "off" {
+		dbAutoMigrate = false
+	}
+	if v := os.Getenv("DB_QUERY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			dbQueryTimeout = d
+		} else {
+			logger.WithField("db_query_timeout", v).Warn("Ignoring invalid DB_QUERY_TIMEOUT")
+		}
+	}
+	if v := os.Getenv("LIST_QUERY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			listQueryTimeout = d
+		} else {
+			logger.WithField("list_query_timeout", v).Warn("Ignoring invalid LIST_QUERY_TIMEOUT")
+		}
+	}
+	if v := os.Getenv("LOCK_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			lockRetries = n
+		} else {
+			logger.WithField("lock_retries", v).Warn("Ignoring invalid LOCK_RETRIES (must be a positive integer)")
+		}
+	}
+	if v := os.Getenv("LOCK_MAX_WAITERS_PER_USER"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxWaitersPerUser = n
+		} else {
+			logger.WithField("lock_max_waiters_per_user", v).Warn("Ignoring invalid LOCK_MAX_WAITERS_PER_USER (must be a positive integer)")
+		}
+	}
+	if v := os.Getenv("LOCK_RETRY_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			lockRetryDelay = d
+		} else {
+			logger.WithField("lock_retry_delay", v).Warn("Ignoring invalid LOCK_RETRY_DELAY")
+		}
+	}
+
+	if v := os.Getenv("USERS_COUNT_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			usersCountTTL = d
+		} else {
+			logger.WithField("users_count_cache_ttl", v).Warn("Ignoring invalid USERS_COUNT_CACHE_TTL")
+		}
+	}
+
+	if v := os.Getenv("GRPC_MAX_SEND_MSG_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > listResponseHeadroom {
+			maxSendMsgSize = n
+		} else {
+			logger.WithField("grpc_max_send_msg_size", v).Warn("Ignoring invalid GRPC_MAX_SEND_MSG_SIZE")
+		}
+	}
+
+	allowedEmailDomains = parseEmailDomains(os.Getenv("ALLOWED_EMAIL_DOMAINS"))
+
+	if v := os.Getenv("TLS_MIN_VERSION"); v != "" {
+		if version, err := tlsversion.Parse(v); err == nil {
+			tlsMinVersion = version
+		} else {
+			logger.WithError(err).Warn("Ignoring invalid TLS_MIN_VERSION")
+		}
+	}
+
+	// Per-method request size limits
+	if v := os.Getenv("GRPC_MAX_RECV_MSG_SIZE_PER_METHOD"); v != "" {
+		if sizes, err := parseMethodSizes(v); err == nil {
+			maxRecvSizeByMethod = sizes
+		} else {
+			logger.WithError(err).Warn("Ignoring invalid GRPC_MAX_RECV_MSG_SIZE_PER_METHOD")
+		}
+	}
+
+	// Fault injection for chaos tests
+	if v := os.Getenv("FAULT_INJECT"); v != "" {
+		if cs, err := parseFaultCodes(v); err == nil {
+			faultCodes = cs
+		} else {
+			logger.WithError(err).Warn("Ignoring invalid FAULT_INJECT")
+		}
+	}
+	if v := os.Getenv("FAULT_INJECT_RATE"); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r >= 0 && r <= 1 {
+			faultRate = r
+		} else {
+			logger.WithField("fault_inject_rate", v).Warn("Ignoring invalid FAULT_INJECT_RATE (must be between 0 and 1)")
+		}
+	}
+	if len(faultCodes) > 0 && faultRate > 0 {
+		logger.WithFields(logrus.Fields{
+			"codes": faultCodes,
+			"rate":  faultRate,
+		}).Warn("FAULT_INJECT is enabled: requests will fail on purpose. Never use this in production")
+	}
+
+	// Input length limits, capped at the column sizes
+	if v := os.Getenv("MAX_NAME_LEN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= maxNameLength {
+			maxNameLen = n
+		} else {
+			logger.WithField("max_name_len", v).Warnf("Ignoring invalid MAX_NAME_LEN (must be between 1 and %d)", maxNameLength)
+		}
+	}
+	if v := os.Getenv("MAX_EMAIL_LEN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= maxEmailLength {
+			maxEmailLen = n
+		} else {
+			logger.WithField("max_email_len", v).Warnf("Ignoring invalid MAX_EMAIL_LEN (must be between 1 and %d)", maxEmailLength)
+		}
+	}
+
+	if v := os.Getenv("REQUIRE_DELETE_CONFIRM"); strings.ToLower(v) == "on" {
+		requireDeleteConfirm = true
+	}
+
+	if v := os.Getenv("SHUTDOWN_DRAIN_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			shutdownDrainDelay = d
+		} else {
+			logger.WithField("shutdown_drain_delay", v).Warn("Ignoring invalid SHUTDOWN_DRAIN_DELAY")
+		}
+	}
+	if v := os.Getenv("SHUTDOWN_STOP_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			shutdownStopTimeout = d
+		} else {
+			logger.WithField("shutdown_stop_timeout", v).Warn("Ignoring invalid SHUTDOWN_STOP_TIMEOUT")
+		}
+	}
+
+	// Per-request info log sampling
+	if v := os.Getenv("LOG_SAMPLE_RATE"); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r >= 0 && r <= 1 {
+			logSampleRate = r
+		} else {
+			logger.WithField("log_sample_rate", v).Warn("Ignoring invalid LOG_SAMPLE_RATE (must be between 0 and 1)")
+		}
+	}
+
+	// Per-request logs returned in the x-debug-log trailer
+	if v := os.Getenv("DEBUG_TRAILERS_ENABLED"); strings.ToLower(v) == "on" {
+		debugTrailersEnabled = true
+	}
+
+	// Debug payload logging
+	if v := os.Getenv("LOG_PAYLOADS"); strings.ToLower(v) == "on" {
+		logPayloads = true
+	}
+	if v := os.Getenv("LOG_PAYLOAD_FORMAT"); v != "" {
+		switch f := strings.ToLower(v); f {
+		case payloadFormatJSON, payloadFormatText:
+			payloadFormat = f
+		default:
+			logger.WithField("log_payload_format", v).Warn("Ignoring invalid LOG_PAYLOAD_FORMAT (must be 'json' or 'text')")
+		}
+	}
+}
+
+// effectivePage normalizes the requested page and limit: missing values fall
+// back to the defaults and oversized limits are clamped to maxPageLimit
+func effectivePage(page, limit int32) (int32, int32) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return page, limit
+}
+
+// pageOffset returns the number of rows before page. Pages whose offset does
+// not fit in an int32 are rejected instead of letting (page-1)*limit wrap
+// around to a negative or wrong offset.
+func pageOffset(page, limit int32) (int32, error) {
+	offset := int64(page-1) * int64(limit)
+	if offset > math.MaxInt32 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid request: page %d is out of range for limit %d", page, limit)
+	}
+	return int32(offset), nil
 }
 
 // maskDSN masks sensitive information in DSN string for logging
@@ -82,6 +392,15 @@ func maskDSN(dsn string) string {
 	return dsn
 }
 
+// maskRedisAddr hides the password of a redis:// URL for logging
+func maskRedisAddr(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil || u.User == nil {
+		return addr
+	}
+	return u.Redacted()
+}
+
 // DBInterface defines the interface for database operations
 type DBInterface interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
@@ -92,8 +411,10 @@ type DBInterface interface {
 // DistributedLocker interface
 // HealthCheck returns error if the external lock system is unhealthy
 // (optional: not all implementations must support)
+// LockUsers locks several users at once for bulk operations (see lockUsersInOrder)
 type DistributedLocker interface {
 	LockUser(ctx context.Context, userID int32) (UnlockFunc, error)
+	LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error)
 	HealthCheck(ctx context.Context) error
 }
 
@@ -101,41 +422,146 @@ type DistributedLocker interface {
 // UnlockFunc is a function type for releasing locks
 type UnlockFunc func()
 
+// lockUsersInOrder acquires the lock of every distinct user in ascending ID
+// order using lockUser and returns a single UnlockFunc that releases them in
+// reverse order. Acquiring in a deterministic order prevents deadlocks between
+// bulk requests that lock overlapping sets of users. If any acquisition fails,
+// the locks taken so far are released before returning the error.
+func lockUsersInOrder(ctx context.Context, lockUser func(context.Context, int32) (UnlockFunc, error), userIDs []int32) (UnlockFunc, error) {
+	ids := append([]int32(nil), userIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	unlocks := make([]UnlockFunc, 0, len(ids))
+	release := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		unlock, err := lockUser(ctx, id)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		unlocks = append(unlocks, unlock)
+	}
+
+	return release, nil
+}
+
 // Redis(Redsync) 구현체
 type RedsyncLocker struct {
-	rsync *redsync.Redsync
-	rdb   *redis.Client // for health check
+	rsync     *redsync.Redsync
+	rdb       *redis.Client // for health check
+	keyPrefix string
+	mutexOpts []redsync.Option
+}
+
+// redsyncMutexOptions returns the retry options from LOCK_RETRIES and
+// LOCK_RETRY_DELAY
+func redsyncMutexOptions() []redsync.Option {
+	var opts []redsync.Option
+	if lockRetries > 0 {
+		opts = append(opts, redsync.WithTries(lockRetries))
+	}
+	if lockRetryDelay > 0 {
+		opts = append(opts, redsync.WithRetryDelay(lockRetryDelay))
+	}
+	return opts
+}
+
+// redisOptions builds the Redis client options. redisAddr is either a plain
+// host:port, combined with REDIS_PASSWORD and REDIS_DB, or a redis:// (or
+// rediss://) URL carrying the password and DB index itself.
+func redisOptions(redisAddr string) (*redis.Options, error) {
+	if strings.HasPrefix(redisAddr, "redis://") || strings.HasPrefix(redisAddr, "rediss://") {
+		opts, err := redis.ParseURL(redisAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_ADDR URL: %w", err)
+		}
+		return opts, nil
+	}
+
+	opts := &redis.Options{
+		Addr:     redisAddr,
+		Password: os.Getenv("REDIS_PASSWORD"),
+	}
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		db, err := strconv.Atoi(v)
+		if err != nil || db < 0 {
+			return nil, fmt.Errorf("invalid REDIS_DB %q: must be a non-negative integer", v)
+		}
+		opts.DB = db
+	}
+	return opts, nil
 }
 
 func NewRedsyncLocker(redisAddr string) *RedsyncLocker {
-	logger.WithField("redis_addr", redisAddr).Info("Initializing Redis locker")
-	rdb := redis.NewClient(&redis.Options{Addr: redisAddr})
+	l, err := openRedsyncLocker(redisAddr)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize Redis locker")
+	}
+	return l
+}
+
+// openRedsyncLocker is NewRedsyncLocker returning an error instead of exiting
+func openRedsyncLocker(redisAddr string) (*RedsyncLocker, error) {
+	opts, err := redisOptions(redisAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis configuration: %w", err)
+	}
+	// Log the parsed address only: a redis:// URL may embed the password
+	redisAddr = opts.Addr
+
+	logger.WithFields(logrus.Fields{
+		"redis_addr": redisAddr,
+		"redis_db":   opts.DB,
+	}).Info("Initializing Redis locker")
+	rdb := redis.NewClient(opts)
 	pool := redsyncredis.NewPool(rdb)
 
 	// Test Redis connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		logger.WithError(err).WithField("redis_addr", redisAddr).Fatal("Failed to connect to Redis")
+		rdb.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", redisAddr, err)
 	}
 
 	logger.WithField("redis_addr", redisAddr).Info("Redis locker initialized successfully")
-	return &RedsyncLocker{rsync: redsync.New(pool), rdb: rdb}
+	return &RedsyncLocker{rsync: redsync.New(pool), rdb: rdb, keyPrefix: lockKeyPrefix, mutexOpts: redsyncMutexOptions()}, nil
+}
+
+// lockKey returns the Redis key guarding a user, e.g. "prod:user-lock-1"
+func (l *RedsyncLocker) lockKey(userID int32) string {
+	if l.keyPrefix == "" {
+		return fmt.Sprintf("user-lock-%d", userID)
+	}
+	return fmt.Sprintf("%s:user-lock-%d", l.keyPrefix, userID)
 }
 
 func (l *RedsyncLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
-	lockKey := fmt.Sprintf("user-lock-%d", userID)
+	lockKey := l.lockKey(userID)
 	logger.WithFields(logrus.Fields{
 		"user_id":  userID,
 		"lock_key": lockKey,
 	}).Debug("Attempting to acquire Redis lock")
 
-	mutex := l.rsync.NewMutex(lockKey)
-	if err := mutex.LockContext(ctx); err != nil {
+	mutex := l.rsync.NewMutex(lockKey, l.mutexOpts...)
+	err := mutex.LockContext(ctx)
+	recordLockOperation(ctx, lockTypeRedis, err)
+	if err != nil {
 		logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":  userID,
 			"lock_key": lockKey,
 		}).Error("Failed to acquire Redis lock")
+		if l.contended(err) {
+			err = fmt.Errorf("%w: %w", errLockContended, err)
+		}
 		return nil, err
 	}
 
@@ -153,6 +579,26 @@ func (l *RedsyncLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc,
 	}, nil
 }
 
+// contended reports whether a LockContext error means the lock was held
+// rather than Redis failing. redsync returns the same ErrFailed when retries
+// run out against an unreachable Redis, so Redis is pinged to tell them apart.
+func (l *RedsyncLocker) contended(err error) bool {
+	var taken *redsync.ErrTaken
+	if !errors.Is(err, redsync.ErrFailed) && !errors.As(err, &taken) {
+		return false
+	}
+	if l.rdb == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return l.rdb.Ping(ctx).Err() == nil
+}
+
+func (l *RedsyncLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	return lockUsersInOrder(ctx, l.LockUser, userIDs)
+}
+
 // RedsyncLocker implements HealthCheck
 func (l *RedsyncLocker) HealthCheck(ctx context.Context) error {
 	if l == nil || l.rdb == nil {
@@ -161,34 +607,112 @@ func (l *RedsyncLocker) HealthCheck(ctx context.Context) error {
 	return l.rdb.Ping(ctx).Err()
 }
 
+// Close closes the Redis client and its connection pool
+func (l *RedsyncLocker) Close() error {
+	if l == nil || l.rdb == nil {
+		return nil
+	}
+	return l.rdb.Close()
+}
+
 // etcd 구현체
 type EtcdLocker struct {
-	client *clientv3.Client
+	client     *clientv3.Client
+	keyPrefix  string
+	sessionTTL int // seconds; 0 uses the etcd default
+
+	// One session for all locks instead of one per lock (ETCD_SESSION_REUSE)
+	reuseSession bool
+	shared       etcdSharedSession
 }
 
-func NewEtcdLocker(endpoints []string) *EtcdLocker {
-	logger.WithField("etcd_endpoints", endpoints).Info("Initializing etcd locker")
-	cli, err := clientv3.New(clientv3.Config{
+// etcdConfig builds the etcd client configuration, adding authentication
+// (ETCD_USERNAME/ETCD_PASSWORD) and TLS (ETCD_TLS_CA_FILE, ETCD_TLS_CERT_FILE,
+// ETCD_TLS_KEY_FILE) when configured
+func etcdConfig(endpoints []string) (clientv3.Config, error) {
+	cfg := clientv3.Config{
 		Endpoints:   endpoints,
 		DialTimeout: 5 * time.Second,
-	})
+		Username:    os.Getenv("ETCD_USERNAME"),
+		Password:    os.Getenv("ETCD_PASSWORD"),
+	}
+
+	tlsConfig, err := etcdTLSConfig(os.Getenv("ETCD_TLS_CA_FILE"), os.Getenv("ETCD_TLS_CERT_FILE"), os.Getenv("ETCD_TLS_KEY_FILE"))
 	if err != nil {
-		logger.WithError(err).WithField("etcd_endpoints", endpoints).Fatal("Failed to connect to etcd")
+		return clientv3.Config{}, err
+	}
+	cfg.TLS = tlsConfig
+
+	return cfg, nil
+}
+
+func NewEtcdLocker(endpoints []string) *EtcdLocker {
+	l, err := openEtcdLocker(endpoints)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize etcd locker")
+	}
+	return l
+}
+
+// openEtcdLocker is NewEtcdLocker returning an error instead of exiting
+func openEtcdLocker(endpoints []string) (*EtcdLocker, error) {
+	logger.WithField("etcd_endpoints", endpoints).Info("Initializing etcd locker")
+	cfg, err := etcdConfig(endpoints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid etcd configuration: %w", err)
+	}
+	logger.WithFields(logrus.Fields{
+		"etcd_auth": cfg.Username != "",
+		"etcd_tls":  cfg.TLS != nil,
+	}).Debug("etcd client configuration")
+
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to etcd: %w", err)
 	}
 
 	logger.WithField("etcd_endpoints", endpoints).Info("etcd locker initialized successfully")
-	return &EtcdLocker{client: cli}
+	return &EtcdLocker{
+		client:       cli,
+		keyPrefix:    lockKeyPrefix,
+		sessionTTL:   int(etcdSessionTTL / time.Second),
+		reuseSession: etcdSessionReuse,
+	}, nil
+}
+
+// lockKey returns the etcd key prefix guarding a user, e.g. "/prod/user-lock-1"
+func (l *EtcdLocker) lockKey(userID int32) string {
+	if l.keyPrefix == "" {
+		return fmt.Sprintf("/user-lock-%d", userID)
+	}
+	return fmt.Sprintf("/%s/user-lock-%d", strings.Trim(l.keyPrefix, "/"), userID)
 }
 
 func (l *EtcdLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
-	lockKey := fmt.Sprintf("/user-lock-%d", userID)
+	lockKey := l.lockKey(userID)
 	logger.WithFields(logrus.Fields{
 		"user_id":  userID,
 		"lock_key": lockKey,
 	}).Debug("Attempting to acquire etcd lock")
 
-	sess, err := concurrency.NewSession(l.client, concurrency.WithContext(ctx))
+	var sess *concurrency.Session
+	var err error
+	releaseLocal := func() {}
+	if l.reuseSession {
+		if releaseLocal, err = l.lockLocal(ctx, lockKey); err != nil {
+			recordLockOperation(ctx, lockTypeEtcd, err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w: %w", errLockContended, err)
+			}
+			return nil, err
+		}
+		sess, err = l.sharedSession()
+	} else {
+		sess, err = concurrency.NewSession(l.client, l.sessionOptions(ctx)...)
+	}
 	if err != nil {
+		releaseLocal()
+		recordLockOperation(ctx, lockTypeEtcd, err)
 		logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":  userID,
 			"lock_key": lockKey,
@@ -197,12 +721,20 @@ func (l *EtcdLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, er
 	}
 
 	mutex := concurrency.NewMutex(sess, lockKey)
-	if err := mutex.Lock(ctx); err != nil {
-		sess.Close()
+	err = mutex.Lock(ctx)
+	recordLockOperation(ctx, lockTypeEtcd, err)
+	if err != nil {
+		if !l.reuseSession {
+			sess.Close()
+		}
+		releaseLocal()
 		logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":  userID,
 			"lock_key": lockKey,
 		}).Error("Failed to acquire etcd lock")
+		if etcdLockContended(sess, err) {
+			err = fmt.Errorf("%w: %w", errLockContended, err)
+		}
 		return nil, err
 	}
 
@@ -212,8 +744,24 @@ func (l *EtcdLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, er
 	}).Debug("etcd lock acquired successfully")
 
 	return func() {
-		mutex.Unlock(ctx)
-		sess.Close()
+		// The request ctx may be done by now; the key must still be deleted
+		unlockCtx, cancel := context.WithTimeout(context.Background(), etcdUnlockTimeout)
+		err := mutex.Unlock(unlockCtx)
+		cancel()
+		if err != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":  userID,
+				"lock_key": lockKey,
+			}).Warn("Failed to release etcd lock")
+		}
+		if l.reuseSession {
+			if err != nil {
+				l.resetSharedSession(sess)
+			}
+			releaseLocal()
+		} else {
+			sess.Close()
+		}
 		logger.WithFields(logrus.Fields{
 			"user_id":  userID,
 			"lock_key": lockKey,
@@ -221,6 +769,10 @@ func (l *EtcdLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, er
 	}, nil
 }
 
+func (l *EtcdLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	return lockUsersInOrder(ctx, l.LockUser, userIDs)
+}
+
 // EtcdLocker implements HealthCheck
 func (l *EtcdLocker) HealthCheck(ctx context.Context) error {
 	if l == nil || l.client == nil {
@@ -236,57 +788,135 @@ type UserServer struct {
 	pb.UnimplementedUserServiceServer
 	db     DBInterface
 	locker DistributedLocker
+
+	// Optional read replicas for GetUser/ListUsers (MYSQL_READ_DSN)
+	replicas    []DBInterface
+	nextReplica uint64
+	replicaLags replicaLagCache
+
+	// In-process fan-out for WatchUsers
+	events *userEventBroker
+
+	// Cached ListUsers total (USERS_COUNT_CACHE_TTL)
+	userCount userCountCache
+
+	// LOCK_TYPE the locker was opened with, reported by ServerInfo
+	lockType string
+
+	// Requests waiting per user lock (LOCK_MAX_WAITERS_PER_USER)
+	waiters userWaiters
+}
+
+// validateServerConfig checks the settings NewUserServer needs before any
+// connection is attempted, so misconfiguration is reported precisely
+func validateServerConfig(mysqlDSN, lockType, redisAddr, etcdEndpoints string) error {
+	if mysqlDSN == "" {
+		return fmt.Errorf("MYSQL_DSN required")
+	}
+	switch strings.ToLower(lockType) {
+	case "":
+		return fmt.Errorf("LOCK_TYPE required (must be 'redis' or 'etcd')")
+	case "redis":
+		if redisAddr == "" {
+			return fmt.Errorf("REDIS_ADDR required for LOCK_TYPE=redis")
+		}
+	case "etcd":
+		if etcdEndpoints == "" {
+			return fmt.Errorf("ETCD_ENDPOINTS required for LOCK_TYPE=etcd")
+		}
+	default:
+		return fmt.Errorf("unknown LOCK_TYPE %q (must be 'redis' or 'etcd')", lockType)
+	}
+	return nil
+}
+
+// openLocker creates the DistributedLocker selected by LOCK_TYPE
+func openLocker(lockType, redisAddr, etcdEndpoints string) (DistributedLocker, error) {
+	switch strings.ToLower(lockType) {
+	case "etcd":
+		l, err := openEtcdLocker(strings.Split(etcdEndpoints, ","))
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	case "redis":
+		l, err := openRedsyncLocker(redisAddr)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("unknown LOCK_TYPE %q (must be 'redis' or 'etcd')", lockType)
 }
 
-func NewUserServer(mysqlDSN, lockType, redisAddr, etcdEndpoints string) *UserServer {
+func NewUserServer(mysqlDSN, lockType, redisAddr, etcdEndpoints string) (*UserServer, error) {
 	logger.WithField("lock_type", lockType).Info("Initializing UserServer")
 
+	if err := validateServerConfig(mysqlDSN, lockType, redisAddr, etcdEndpoints); err != nil {
+		return nil, err
+	}
+
 	// MySQL 연결
 	logger.WithField("mysql_dsn", maskDSN(mysqlDSN)).Info("Connecting to MySQL database")
-	db, err := sql.Open("mysql", mysqlDSN)
+	dsn, err := buildDSN(mysqlDSN, dbStatementTimeout)
 	if err != nil {
-		logger.WithError(err).WithField("mysql_dsn", maskDSN(mysqlDSN)).Fatal("Failed to open MySQL connection")
+		return nil, fmt.Errorf("failed to parse MYSQL_DSN: %w", err)
+	}
+	if dbStatementTimeout > 0 {
+		logger.WithField("db_statement_timeout", dbStatementTimeout).Info("MySQL statement timeout enabled")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MySQL connection: %w", err)
 	}
-
-	mainDB = db // for health check
 
 	if err := db.Ping(); err != nil {
-		logger.WithError(err).WithField("mysql_dsn", maskDSN(mysqlDSN)).Fatal("Failed to ping MySQL database")
+		db.Close()
+		return nil, fmt.Errorf("failed to ping MySQL database: %w", err)
 	}
 
 	logger.Info("MySQL connection established successfully")
 
-	if err := initDB(db); err != nil {
-		logger.WithError(err).Fatal("Failed to initialize database schema")
+	if err := prepareSchema(db); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	logger.Info("Database schema initialized successfully")
+	mainDB = db // for health check
 
-	// 분산 락 구현체 선택
-	var locker DistributedLocker
-	switch strings.ToLower(lockType) {
-	case "etcd":
-		if etcdEndpoints == "" {
-			logger.Fatal("ETCD_ENDPOINTS must be set for etcd lock type")
-		}
-		endpoints := strings.Split(etcdEndpoints, ",")
-		locker = NewEtcdLocker(endpoints)
-	case "redis":
-		if redisAddr == "" {
-			logger.Fatal("REDIS_ADDR must be set for redis lock type")
+	var replicas []DBInterface
+	if readDSNs := os.Getenv("MYSQL_READ_DSN"); readDSNs != "" {
+		if replicas, err = openReplicas(readDSNs); err != nil {
+			db.Close()
+			return nil, err
 		}
-		locker = NewRedsyncLocker(redisAddr)
-	default:
-		logger.WithField("lock_type", lockType).Fatal("Unknown LOCK_TYPE (must be 'redis' or 'etcd')")
+		logger.WithField("replicas", len(replicas)).Info("MySQL read replicas configured")
+	}
+
+	// 분산 락 구현체 선택
+	locker, err := openLocker(lockType, redisAddr, etcdEndpoints)
+	if err != nil {
+		closeDBs(replicas)
+		db.Close()
+		return nil, err
+	}
+
+	if lockBreakerFailures > 0 {
+		locker = newBreakerLocker(locker, lockBreakerFailures, lockBreakerTimeout)
 	}
 
 	globalLocker = locker // for health check
 
+	go runUsersTotalRefresher(context.Background(), db, usersTotalRefreshInterval)
+
 	logger.Info("UserServer initialized successfully")
 	return &UserServer{
-		db:     db,
-		locker: locker,
-	}
+		db:       db,
+		locker:   &reentrantLocker{locker},
+		replicas: replicas,
+		events:   newUserEventBroker(),
+		lockType: strings.ToLower(lockType),
+	}, nil
 }
 
 // Exported for testing
@@ -295,107 +925,409 @@ func NewUserServer(mysqlDSN, lockType, redisAddr, etcdEndpoints string) *UserSer
 func NewUserServerWithDB(db DBInterface, locker DistributedLocker) *UserServer {
 	return &UserServer{
 		db:     db,
-		locker: locker,
+		locker: &reentrantLocker{locker},
+		events: newUserEventBroker(),
 	}
 }
 
+// NewUserServerWithReplicas is NewUserServerWithDB with read replicas that
+// serve GetUser and ListUsers
+func NewUserServerWithReplicas(db DBInterface, replicas []DBInterface, locker DistributedLocker) *UserServer {
+	return &UserServer{
+		db:       db,
+		locker:   &reentrantLocker{locker},
+		replicas: replicas,
+		events:   newUserEventBroker(),
+	}
+}
+
+// prepareSchema creates the users table unless DB_AUTO_MIGRATE=off, then
+// verifies that the schema has every column the handlers use
+func prepareSchema(db *sql.DB) error {
+	if dbAutoMigrate {
+		if err := initDB(db); err != nil {
+			return fmt.Errorf("failed to initialize database schema: %w", err)
+		}
+		logger.Info("Database schema initialized successfully")
+	} else {
+		logger.Info("DB_AUTO_MIGRATE=off: skipping schema initialization")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := checkUsersSchema(ctx, db); err != nil {
+		return fmt.Errorf("database schema validation failed: %w", err)
+	}
+	return nil
+}
+
 func initDB(db *sql.DB) error {
 	query := `CREATE TABLE IF NOT EXISTS users (
 		id INT AUTO_INCREMENT PRIMARY KEY,
 		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) NOT NULL,
+		email VARCHAR(255) NOT NULL UNIQUE,
 		age INT NOT NULL,
 		created_at VARCHAR(64) NOT NULL,
 		updated_at VARCHAR(64) NOT NULL
 	);`
-	_, err := db.Exec(query)
-	return err
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created before email was UNIQUE lack the index, and CREATE TABLE
+	// IF NOT EXISTS leaves them as they are
+	hasIndex, err := hasEmailUniqueIndex(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if !hasIndex {
+		logger.Info("Adding unique index on users.email")
+		if _, err := db.Exec(`ALTER TABLE users ADD UNIQUE INDEX uniq_users_email (email)`); err != nil {
+			return fmt.Errorf("failed to add unique index on users.email (remove duplicate emails first): %w", err)
+		}
+	}
+	return nil
+}
+
+// lockUser acquires the lock for a single user on behalf of a handler,
+// counting the access for /hotusers and tracking the request in the
+// lock_waiters gauge while it waits
+func (s *UserServer) lockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
+	hotUsers.record(userID)
+	leave, err := s.waiters.enter(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	lockWaiters.Inc()
+	defer lockWaiters.Dec()
+	return s.locker.LockUser(ctx, userID)
+}
+
+// withUserLock runs fn while holding the lock of userID. Mutation handlers put
+// their database work in fn so that the lock is released on every return path,
+// including errors and panics. The hold time is recorded in
+// lock_hold_duration_seconds.
+func (s *UserServer) withUserLock(ctx context.Context, userID int32, fn func() error) error {
+	unlock, err := s.lockUser(ctx, userID)
+	if err != nil {
+		entry := requestLog(ctx).WithError(err).WithField("user_id", userID)
+		if method, ok := grpc.Method(ctx); ok {
+			entry = entry.WithField("method", method)
+		}
+		entry.Error("Failed to acquire user lock")
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	acquired := time.Now()
+	defer func() {
+		unlock()
+		lockHoldDuration.Observe(time.Since(acquired).Seconds())
+	}()
+
+	return fn()
+}
+
+// lockUsers is the bulk counterpart of lockUser
+func (s *UserServer) lockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	for _, id := range userIDs {
+		hotUsers.record(id)
+	}
+	leave, err := s.waiters.enter(userIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	lockWaiters.Inc()
+	defer lockWaiters.Dec()
+	return s.locker.LockUsers(ctx, userIDs)
 }
 
 func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	logger.WithField("user_id", req.Id).Info("GetUser request received")
+	requestLog(ctx).WithField("user_id", req.Id).Info("GetUser request received")
 
-	unlock, err := s.locker.LockUser(ctx, req.Id)
+	unlock, err := s.lockUser(ctx, req.Id)
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to acquire lock for GetUser")
+		requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Failed to acquire lock for GetUser")
+		getUserResultTotal.WithLabelValues(getUserError).Inc()
 		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 	defer unlock()
 
-	row := s.db.QueryRowContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`, req.Id)
+	dbCtx, cancel := withDBTimeout(ctx)
+	defer cancel()
+
 	var user pb.User
-	err = row.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+	scan := func(row *sql.Row) error {
+		return row.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+	}
+	const query = `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`
+	done := timeDBQuery(dbOpGet)
+	if requireDeleteConfirm {
+		// DeleteUser checks the confirm token against the primary, so a
+		// token issued from a lagging replica would be refused as stale
+		err = scan(s.db.QueryRowContext(dbCtx, query, req.Id))
+	} else {
+		err = s.queryRowRead(dbCtx, scan, query, req.Id)
+	}
+	done()
 	if err == sql.ErrNoRows {
-		logger.WithField("user_id", req.Id).Warn("User not found")
+		requestLog(ctx).WithField("user_id", req.Id).Warn("User not found")
+		getUserResultTotal.WithLabelValues(getUserNotFound).Inc()
 		return &pb.GetUserResponse{Success: false, Message: "User not found"}, nil
 	} else if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Database error in GetUser")
+		requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Database error in GetUser")
+		getUserResultTotal.WithLabelValues(getUserError).Inc()
 		return nil, err
 	}
 
-	logger.WithFields(logrus.Fields{
+	requestLog(ctx).WithFields(logrus.Fields{
 		"user_id":    req.Id,
 		"user_name":  user.Name,
-		"user_email": user.Email,
+		"user_email": redact.Email(user.Email),
 	}).Info("User retrieved successfully")
+	getUserResultTotal.WithLabelValues(getUserFound).Inc()
+
+	return &pb.GetUserResponse{
+		User:         &user,
+		Success:      true,
+		Message:      "User found successfully",
+		ConfirmToken: deleteConfirmToken(&user),
+	}, nil
+}
+
+// usersETag returns a stable FNV-1a hash over the ids and updated_at of a
+// result set. Users are hashed in id order so the etag does not depend on the
+// order rows come back from the database.
+func usersETag(users []*pb.User) string {
+	sorted := append([]*pb.User(nil), users...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
 
-	return &pb.GetUserResponse{User: &user, Success: true, Message: "User found successfully"}, nil
+	h := fnv.New64a()
+	for _, u := range sorted {
+		fmt.Fprintf(h, "%d:%s\n", u.Id, u.UpdatedAt)
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
 }
 
 func (s *UserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	logger.WithFields(logrus.Fields{
+	requestLog(ctx).WithFields(logrus.Fields{
 		"page":  req.Page,
 		"limit": req.Limit,
 	}).Info("ListUsers request received")
 
-	rows, err := s.db.QueryContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users`)
+	page, limit := effectivePage(req.Page, req.Limit)
+	if req.Limit > limit {
+		requestLog(ctx).WithFields(logrus.Fields{
+			"requested_limit": req.Limit,
+			"effective_limit": limit,
+		}).Warn("ListUsers limit clamped to maximum")
+	}
+	// SetHeader only fails outside a gRPC server (e.g. direct calls in tests)
+	grpc.SetHeader(ctx, metadata.Pairs(pageLimitHeader, strconv.Itoa(int(limit))))
+
+	columns, err := userProjection(req.Fields)
+	if err != nil {
+		requestLog(ctx).WithField("fields", req.Fields).Warn("Invalid ListUsers fields")
+		return nil, err
+	}
+	selectList := strings.Join(columns, ", ")
+
+	offset, err := pageOffset(page, limit)
 	if err != nil {
-		logger.WithError(err).Error("Database error in ListUsers")
+		requestLog(ctx).WithFields(logrus.Fields{"page": page, "limit": limit}).Warn("ListUsers page out of range")
+		return nil, err
+	}
+	dbCtx, cancel := withDBTimeout(ctx)
+	defer cancel()
+	listCtx, listCancel := withListTimeout(dbCtx)
+	defer listCancel()
+
+	var rows *sql.Rows
+	var servedBy DBInterface
+	done := timeDBQuery(dbOpList)
+	if req.PageToken != "" {
+		afterID, tokenErr := decodePageToken(req.PageToken)
+		if tokenErr != nil {
+			requestLog(ctx).WithField("page_token", req.PageToken).Warn("Invalid ListUsers page token")
+			return nil, tokenErr
+		}
+		rows, servedBy, err = s.queryReadFrom(listCtx, `SELECT `+selectList+` FROM users WHERE id > ? ORDER BY id ASC LIMIT ?`, afterID, limit)
+	} else {
+		rows, servedBy, err = s.queryReadFrom(listCtx, `SELECT `+selectList+` FROM users ORDER BY id ASC LIMIT ? OFFSET ?`, limit, offset)
+	}
+	done()
+	if err != nil {
+		if timeoutErr := listTimeoutError(ctx, listCtx, "ListUsers", err); timeoutErr != nil {
+			requestLog(ctx).WithError(err).WithField("offset", offset).Warn("ListUsers query timed out")
+			return nil, timeoutErr
+		}
+		requestLog(ctx).WithError(err).Error("Database error in ListUsers")
 		return nil, err
 	}
 	defer rows.Close()
 
+	// Keep the response under maxSendMsgSize; a page cut short by the budget
+	// is completed through next_page_token
+	budget := maxSendMsgSize - listResponseHeadroom
 	var users []*pb.User
+	var truncated bool
 	for rows.Next() {
+		// Stop scanning for a client that has gone away
+		if err := ctx.Err(); err != nil {
+			requestLog(ctx).WithError(err).WithField("scanned_users", len(users)).Warn("ListUsers cancelled during scan")
+			return nil, status.FromContextError(err).Err()
+		}
+
 		var user pb.User
-		err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(userScanTargets(&user, columns)...)
 		if err != nil {
-			logger.WithError(err).Error("Error scanning user row in ListUsers")
+			requestLog(ctx).WithError(err).Error("Error scanning user row in ListUsers")
 			return nil, err
 		}
+		size := listUserSize(&user)
+		if size > budget {
+			if len(users) == 0 {
+				requestLog(ctx).WithField("user_id", user.Id).Error("User row exceeds GRPC_MAX_SEND_MSG_SIZE in ListUsers")
+				return nil, status.Errorf(codes.ResourceExhausted, "user %d does not fit in a response of %d bytes", user.Id, maxSendMsgSize)
+			}
+			truncated = true
+			break
+		}
+		budget -= size
 		users = append(users, &user)
 	}
+	if err := rows.Err(); err != nil && !truncated {
+		if timeoutErr := listTimeoutError(ctx, listCtx, "ListUsers", err); timeoutErr != nil {
+			requestLog(ctx).WithError(err).WithField("offset", offset).Warn("ListUsers query timed out")
+			return nil, timeoutErr
+		}
+		requestLog(ctx).WithError(err).Error("Error iterating user rows in ListUsers")
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, err
+	}
+	if truncated {
+		requestLog(ctx).WithFields(logrus.Fields{
+			"returned_users": len(users),
+			"limit":          limit,
+		}).Warn("ListUsers page truncated to fit GRPC_MAX_SEND_MSG_SIZE")
+	}
+
+	var nextPageToken string
+	if len(users) > 0 && (truncated || int32(len(users)) == limit) {
+		nextPageToken = encodePageToken(users[len(users)-1].Id)
+	}
+
+	servedFrom, lag := s.readFreshness(dbCtx, servedBy)
+
+	total, approximate, err := s.countUsers(dbCtx)
+	if err != nil {
+		// The page itself is fine; report what is known to exist instead of failing
+		requestLog(ctx).WithError(err).Warn("Failed to count users in ListUsers, returning a lower bound")
+		total, approximate = int64(offset)+int64(len(users)), true
+	}
+
+	etag := usersETag(users)
+	if len(req.Fields) > 0 && !slices.Contains(columns, "updated_at") {
+		etag = projectedUsersETag(users)
+	}
+	if req.IfNoneMatch != "" && req.IfNoneMatch == etag {
+		requestLog(ctx).WithField("etag", etag).Info("Users not modified")
+		return &pb.ListUsersResponse{
+			Total:             int32(total),
+			TotalApproximate:  approximate,
+			Success:           true,
+			Message:           "Users not modified",
+			Etag:              etag,
+			NotModified:       true,
+			NextPageToken:     nextPageToken,
+			ServedFrom:        servedFrom,
+			ReplicaLagSeconds: lag,
+		}, nil
+	}
 
-	logger.WithField("total_users", len(users)).Info("Users listed successfully")
+	requestLog(ctx).WithFields(logrus.Fields{
+		"page_users":  len(users),
+		"total_users": total,
+	}).Info("Users listed successfully")
 
 	return &pb.ListUsersResponse{
-		Users:   users,
-		Total:   int32(len(users)),
-		Success: true,
-		Message: "Users retrieved successfully",
+		Users:             users,
+		Total:             int32(total),
+		TotalApproximate:  approximate,
+		Success:           true,
+		Message:           "Users retrieved successfully",
+		Etag:              etag,
+		NextPageToken:     nextPageToken,
+		ServedFrom:        servedFrom,
+		ReplicaLagSeconds: lag,
 	}, nil
 }
 
+// emailConflict builds the AlreadyExists error of a duplicate email. The user
+// already holding the email is looked up on the primary, where the losing
+// insert saw it, and named in an errdetails.ResourceInfo so clients can point
+// to it. If the lookup fails the error carries no details.
+func (s *UserServer) emailConflict(ctx context.Context, email string) error {
+	st := status.New(codes.AlreadyExists, "email already exists")
+
+	var id int32
+	if err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ?`, email).Scan(&id); err != nil {
+		requestLog(ctx).WithError(err).WithField("user_email", redact.Email(email)).Warn("Failed to look up the user holding a duplicate email")
+		return st.Err()
+	}
+	if withDetails, err := st.WithDetails(&errdetails.ResourceInfo{
+		ResourceType: "user",
+		ResourceName: strconv.Itoa(int(id)),
+		Description:  fmt.Sprintf("email already belongs to user %d", id),
+	}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// CreateUser inserts a new user. It takes no distributed lock: concurrent
+// creates with the same email are settled by the unique index on
+// users.email, and every loser gets AlreadyExists.
 func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	logger.WithFields(logrus.Fields{
+	requestLog(ctx).WithFields(logrus.Fields{
 		"user_name":  req.Name,
-		"user_email": req.Email,
+		"user_email": redact.Email(req.Email),
 		"user_age":   req.Age,
 	}).Info("CreateUser request received")
 
+	if err := checkWritable("CreateUser"); err != nil {
+		return nil, err
+	}
+
+	if err := validateUserFields(req.Name, req.Email, req.Age); err != nil {
+		requestLog(ctx).WithError(err).Warn("Invalid CreateUser request")
+		return nil, err
+	}
+
+	dbCtx, cancel := withDBTimeout(ctx)
+	defer cancel()
+
 	now := time.Now().Format(time.RFC3339)
-	res, err := s.db.ExecContext(ctx, `INSERT INTO users (name, email, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`, req.Name, req.Email, req.Age, now, now)
+	done := timeDBQuery(dbOpCreate)
+	res, err := s.db.ExecContext(dbCtx, `INSERT INTO users (name, email, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`, req.Name, req.Email, req.Age, now, now)
+	done()
+	if isDuplicateEntry(err) {
+		requestLog(ctx).WithField("user_email", redact.Email(req.Email)).Warn("Duplicate email in CreateUser")
+		return nil, s.emailConflict(dbCtx, req.Email)
+	}
 	if err != nil {
-		logger.WithError(err).WithFields(logrus.Fields{
+		requestLog(ctx).WithError(err).WithFields(logrus.Fields{
 			"user_name":  req.Name,
-			"user_email": req.Email,
+			"user_email": redact.Email(req.Email),
 		}).Error("Database error in CreateUser")
 		return nil, err
 	}
 
-	id, err := res.LastInsertId()
+	id, err := s.insertedUserID(dbCtx, res, req.Email)
 	if err != nil {
-		logger.WithError(err).Error("Failed to get last insert ID in CreateUser")
+		requestLog(ctx).WithError(err).Error("Failed to get last insert ID in CreateUser")
 		return nil, err
 	}
 
@@ -408,98 +1340,349 @@ func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		UpdatedAt: now,
 	}
 
-	logger.WithFields(logrus.Fields{
+	requestLog(ctx).WithFields(logrus.Fields{
 		"user_id":    user.Id,
 		"user_name":  user.Name,
-		"user_email": user.Email,
+		"user_email": redact.Email(user.Email),
 	}).Info("User created successfully")
+	usersCreatedTotal.Inc()
+	s.userCount.invalidate()
+	s.events.publish(newUserEvent(pb.UserEvent_CREATED, user))
 
 	return &pb.CreateUserResponse{User: user, Success: true, Message: "User created successfully"}, nil
 }
 
 func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	logger.WithFields(logrus.Fields{
+	requestLog(ctx).WithFields(logrus.Fields{
 		"user_id":    req.Id,
 		"user_name":  req.Name,
-		"user_email": req.Email,
+		"user_email": redact.Email(req.Email),
 		"user_age":   req.Age,
 	}).Info("UpdateUser request received")
 
-	unlock, err := s.locker.LockUser(ctx, req.Id)
-	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to acquire lock for UpdateUser")
-		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	if err := checkWritable("UpdateUser"); err != nil {
+		return nil, err
 	}
-	defer unlock()
 
-	now := time.Now().Format(time.RFC3339)
-	res, err := s.db.ExecContext(ctx, `UPDATE users SET name=?, email=?, age=?, updated_at=? WHERE id=?`, req.Name, req.Email, req.Age, now, req.Id)
-	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Database error in UpdateUser")
+	if err := validateUserFields(req.Name, req.Email, req.Age); err != nil {
+		requestLog(ctx).WithError(err).WithField("user_id", req.Id).Warn("Invalid UpdateUser request")
 		return nil, err
 	}
 
-	num, err := res.RowsAffected()
+	var resp *pb.UpdateUserResponse
+	err := s.withUserLock(ctx, req.Id, func() error {
+		defer timeDBQuery(dbOpUpdate)()
+		ctx, cancel := withDBTimeout(ctx)
+		defer cancel()
+
+		if req.SkipUnchanged {
+			current, err := s.unchangedUser(ctx, req)
+			if err != nil {
+				return err
+			}
+			if current != nil {
+				requestLog(ctx).WithField("user_id", req.Id).Info("UpdateUser skipped: no changes")
+				resp = &pb.UpdateUserResponse{User: current, Success: true, Message: "No changes"}
+				return nil
+			}
+		}
+
+		now := time.Now().Format(time.RFC3339)
+		res, err := s.db.ExecContext(ctx, `UPDATE users SET name=?, email=?, age=?, updated_at=? WHERE id=?`, req.Name, req.Email, req.Age, now, req.Id)
+		if isDuplicateEntry(err) {
+			requestLog(ctx).WithFields(logrus.Fields{
+				"user_id":    req.Id,
+				"user_email": redact.Email(req.Email),
+			}).Warn("Duplicate email in UpdateUser")
+			return s.emailConflict(ctx, req.Email)
+		}
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Database error in UpdateUser")
+			return err
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Failed to get rows affected in UpdateUser")
+			return err
+		}
+
+		if num == 0 {
+			requestLog(ctx).WithField("user_id", req.Id).Warn("User not found for update")
+			resp = &pb.UpdateUserResponse{Success: false, Message: "User not found"}
+			return nil
+		}
+
+		row := s.db.QueryRowContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`, req.Id)
+		var user pb.User
+		err = row.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+		if err == sql.ErrNoRows {
+			// Deleted by another process between the UPDATE and this SELECT
+			requestLog(ctx).WithField("user_id", req.Id).Warn("User disappeared after update")
+			return status.Error(codes.NotFound, "user was modified concurrently")
+		} else if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Failed to retrieve updated user")
+			return err
+		}
+
+		requestLog(ctx).WithFields(logrus.Fields{
+			"user_id":    user.Id,
+			"user_name":  user.Name,
+			"user_email": redact.Email(user.Email),
+		}).Info("User updated successfully")
+		s.events.publish(newUserEvent(pb.UserEvent_UPDATED, &user))
+
+		resp = &pb.UpdateUserResponse{User: &user, Success: true, Message: "User updated successfully"}
+		return nil
+	})
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to get rows affected in UpdateUser")
 		return nil, err
 	}
+	return resp, nil
+}
 
-	if num == 0 {
-		logger.WithField("user_id", req.Id).Warn("User not found for update")
-		return &pb.UpdateUserResponse{Success: false, Message: "User not found"}, nil
-	}
-
-	row := s.db.QueryRowContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`, req.Id)
+// unchangedUser returns the current row of req.Id if req would not change it,
+// or nil if the update must go ahead (including when the user does not exist,
+// which the UPDATE then reports). It must be called with the user's lock held.
+func (s *UserServer) unchangedUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
 	var user pb.User
-	err = row.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+	err := s.db.QueryRowContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`, req.Id).
+		Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to retrieve updated user")
+		requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Failed to read current user in UpdateUser")
 		return nil, err
 	}
+	if user.Name != req.Name || user.Email != req.Email || user.Age != req.Age {
+		return nil, nil
+	}
+	return &user, nil
+}
 
-	logger.WithFields(logrus.Fields{
-		"user_id":    user.Id,
-		"user_name":  user.Name,
-		"user_email": user.Email,
-	}).Info("User updated successfully")
+// insertedUserID returns the id of the row CreateUser just inserted. Drivers
+// that cannot report LastInsertId get it looked up by email instead, which
+// the unique index on users.email makes unambiguous.
+func (s *UserServer) insertedUserID(ctx context.Context, res sql.Result, email string) (int64, error) {
+	id, err := res.LastInsertId()
+	if err == nil {
+		return id, nil
+	}
+	requestLog(ctx).WithError(err).Warn("LastInsertId unsupported in CreateUser, looking up the inserted row")
 
-	return &pb.UpdateUserResponse{User: &user, Success: true, Message: "User updated successfully"}, nil
+	if lookupErr := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ?`, email).Scan(&id); lookupErr != nil {
+		return 0, fmt.Errorf("%w (lookup of inserted row failed: %v)", err, lookupErr)
+	}
+	return id, nil
 }
 
-func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
-	logger.WithField("user_id", req.Id).Info("DeleteUser request received")
+// txBeginner is implemented by *sql.DB; bulk RPCs that need a transaction
+// type-assert the server's DBInterface against it
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// UpdateUsers applies several updates in a single transaction while holding
+// the locks of every affected user. Users that do not exist are reported as
+// not updated in their per-record result instead of failing the batch.
+func (s *UserServer) UpdateUsers(ctx context.Context, req *pb.UpdateUsersRequest) (*pb.UpdateUsersResponse, error) {
+	requestLog(ctx).WithField("count", len(req.Users)).Info("UpdateUsers request received")
+
+	if err := checkWritable("UpdateUsers"); err != nil {
+		return nil, err
+	}
 
-	unlock, err := s.locker.LockUser(ctx, req.Id)
+	if len(req.Users) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid request: users is required")
+	}
+
+	ids := make([]int32, len(req.Users))
+	for i, u := range req.Users {
+		if err := validateUserFields(u.Name, u.Email, u.Age); err != nil {
+			requestLog(ctx).WithError(err).WithFields(logrus.Fields{"index": i, "user_id": u.Id}).Warn("Invalid UpdateUsers request")
+			return nil, err
+		}
+		ids[i] = u.Id
+	}
+
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "database does not support transactions")
+	}
+
+	unlock, err := s.lockUsers(ctx, ids)
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to acquire lock for DeleteUser")
+		requestLog(ctx).WithError(err).WithField("user_ids", ids).Error("Failed to acquire locks for UpdateUsers")
 		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 	defer unlock()
 
-	res, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id=?`, req.Id)
+	defer timeDBQuery(dbOpUpdate)()
+	ctx, cancel := withDBTimeout(ctx)
+	defer cancel()
+
+	tx, err := beginner.BeginTx(ctx, nil)
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Database error in DeleteUser")
+		requestLog(ctx).WithError(err).Error("Failed to begin transaction for UpdateUsers")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	resp := &pb.UpdateUsersResponse{Results: make([]*pb.UpdateUserResult, 0, len(req.Users))}
+	now := time.Now().Format(time.RFC3339)
+	for _, u := range req.Users {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET name=?, email=?, age=?, updated_at=? WHERE id=?`, u.Name, u.Email, u.Age, now, u.Id)
+		if isDuplicateEntry(err) {
+			requestLog(ctx).WithFields(logrus.Fields{
+				"user_id":    u.Id,
+				"user_email": redact.Email(u.Email),
+			}).Warn("Duplicate email in UpdateUsers")
+			// Roll back first so the lookup does not wait on this batch's rows
+			tx.Rollback()
+			return nil, s.emailConflict(ctx, u.Email)
+		}
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", u.Id).Error("Database error in UpdateUsers")
+			return nil, err
+		}
+		num, err := res.RowsAffected()
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", u.Id).Error("Failed to get rows affected in UpdateUsers")
+			return nil, err
+		}
+		if num == 0 {
+			resp.Results = append(resp.Results, &pb.UpdateUserResult{Id: u.Id})
+			resp.NotFoundCount++
+			continue
+		}
+
+		var user pb.User
+		row := tx.QueryRowContext(ctx, `SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = ?`, u.Id)
+		if err := row.Scan(&user.Id, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", u.Id).Error("Failed to retrieve updated user")
+			return nil, err
+		}
+		resp.Results = append(resp.Results, &pb.UpdateUserResult{Id: u.Id, Updated: true, User: &user})
+		resp.UpdatedCount++
+	}
+
+	if err := tx.Commit(); err != nil {
+		requestLog(ctx).WithError(err).Error("Failed to commit UpdateUsers transaction")
 		return nil, err
 	}
 
-	num, err := res.RowsAffected()
+	requestLog(ctx).WithFields(logrus.Fields{
+		"updated":   resp.UpdatedCount,
+		"not_found": resp.NotFoundCount,
+	}).Info("Users updated successfully")
+	for _, r := range resp.Results {
+		if r.Updated {
+			s.events.publish(newUserEvent(pb.UserEvent_UPDATED, r.User))
+		}
+	}
+
+	resp.Success = true
+	resp.Message = fmt.Sprintf("Updated %d users, %d not found", resp.UpdatedCount, resp.NotFoundCount)
+	return resp, nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	requestLog(ctx).WithField("user_id", req.Id).Info("DeleteUser request received")
+
+	if err := checkWritable("DeleteUser"); err != nil {
+		return nil, err
+	}
+
+	var resp *pb.DeleteUserResponse
+	err := s.withUserLock(ctx, req.Id, func() error {
+		defer timeDBQuery(dbOpDelete)()
+		ctx, cancel := withDBTimeout(ctx)
+		defer cancel()
+
+		if requireDeleteConfirm || req.ConfirmToken != "" {
+			found, err := s.checkDeleteConfirmToken(ctx, req.Id, req.ConfirmToken)
+			if err != nil {
+				requestLog(ctx).WithError(err).WithField("user_id", req.Id).Warn("DeleteUser confirmation failed")
+				return err
+			}
+			if !found {
+				requestLog(ctx).WithField("user_id", req.Id).Warn("User not found for deletion")
+				resp = &pb.DeleteUserResponse{Success: false, Message: "User not found"}
+				return nil
+			}
+		}
+
+		res, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id=?`, req.Id)
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Database error in DeleteUser")
+			return err
+		}
+
+		num, err := res.RowsAffected()
+		if err != nil {
+			requestLog(ctx).WithError(err).WithField("user_id", req.Id).Error("Failed to get rows affected in DeleteUser")
+			return err
+		}
+
+		if num == 0 {
+			requestLog(ctx).WithField("user_id", req.Id).Warn("User not found for deletion")
+			resp = &pb.DeleteUserResponse{Success: false, Message: "User not found"}
+			return nil
+		}
+
+		requestLog(ctx).WithField("user_id", req.Id).Info("User deleted successfully")
+		usersDeletedTotal.Inc()
+		s.userCount.invalidate()
+		s.events.publish(newUserEvent(pb.UserEvent_DELETED, &pb.User{Id: req.Id}))
+		resp = &pb.DeleteUserResponse{Success: true, Message: "User deleted successfully"}
+		return nil
+	})
 	if err != nil {
-		logger.WithError(err).WithField("user_id", req.Id).Error("Failed to get rows affected in DeleteUser")
 		return nil, err
 	}
+	return resp, nil
+}
 
-	if num == 0 {
-		logger.WithField("user_id", req.Id).Warn("User not found for deletion")
-		return &pb.DeleteUserResponse{Success: false, Message: "User not found"}, nil
+// keepaliveServerParameters reads GRPC_MAX_CONNECTION_IDLE,
+// GRPC_MAX_CONNECTION_AGE and GRPC_MAX_CONNECTION_AGE_GRACE (durations such as
+// "5m"). ok is false when none is set, leaving gRPC's unlimited defaults.
+func keepaliveServerParameters() (params keepalive.ServerParameters, ok bool, err error) {
+	fields := []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"GRPC_MAX_CONNECTION_IDLE", &params.MaxConnectionIdle},
+		{"GRPC_MAX_CONNECTION_AGE", &params.MaxConnectionAge},
+		{"GRPC_MAX_CONNECTION_AGE_GRACE", &params.MaxConnectionAgeGrace},
+	}
+	for _, f := range fields {
+		v := os.Getenv(f.env)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return keepalive.ServerParameters{}, false, fmt.Errorf("invalid %s %q: must be a positive duration", f.env, v)
+		}
+		*f.dst = d
+		ok = true
 	}
+	return params, ok, nil
+}
 
-	logger.WithField("user_id", req.Id).Info("User deleted successfully")
-	return &pb.DeleteUserResponse{Success: true, Message: "User deleted successfully"}, nil
+// listenAddress combines the LISTEN_ADDR host (e.g. "127.0.0.1") with the
+// port; an empty host listens on all interfaces
+func listenAddress(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
 }
 
 func RunServer(port int) error {
-	logger.WithField("port", port).Info("Starting gRPC server")
+	logger.WithFields(logrus.Fields{
+		"port":       port,
+		"version":    Version,
+		"git_commit": GitCommit,
+	}).Info("Starting gRPC server")
 
 	mysqlDSN := os.Getenv("MYSQL_DSN") // 예: "user:password@tcp(localhost:3306)/dbname"
 	lockType := os.Getenv("LOCK_TYPE") // "redis" or "etcd"
@@ -509,61 +1692,105 @@ func RunServer(port int) error {
 	logger.WithFields(logrus.Fields{
 		"mysql_dsn":      maskDSN(mysqlDSN),
 		"lock_type":      lockType,
-		"redis_addr":     redisAddr,
+		"redis_addr":     maskRedisAddr(redisAddr),
 		"etcd_endpoints": etcdEndpoints,
 	}).Info("Server configuration loaded")
 
-	if mysqlDSN == "" || lockType == "" {
-		logger.Fatal("MYSQL_DSN and LOCK_TYPE environment variables must be set")
+	// TLS (mTLS when CLIENT_CA_FILE is set); plaintext needs ALLOW_INSECURE=on
+	tlsCertFile := os.Getenv("TLS_CERT_FILE")
+	tlsKeyFile := os.Getenv("TLS_KEY_FILE")
+	clientCAFile := os.Getenv("CLIENT_CA_FILE")
+	allowInsecure := strings.ToLower(os.Getenv("ALLOW_INSECURE")) == "on"
+	var tlsOpt grpc.ServerOption
+	if tlsCertFile != "" || tlsKeyFile != "" {
+		tlsConfig, err := serverTLSConfig(tlsCertFile, tlsKeyFile, clientCAFile)
+		if err != nil {
+			logger.WithError(err).Error("Failed to configure TLS")
+			return err
+		}
+		tlsOpt = grpc.Creds(credentials.NewTLS(tlsConfig))
+		logger.WithField("mtls", clientCAFile != "").Info("TLS enabled for gRPC server")
+	} else if clientCAFile != "" {
+		return fmt.Errorf("CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	} else if !allowInsecure {
+		return fmt.Errorf("refusing to start without TLS: set TLS_CERT_FILE and TLS_KEY_FILE, or ALLOW_INSECURE=on for plaintext")
+	} else {
+		logger.Warn("Running gRPC server WITHOUT TLS (ALLOW_INSECURE=on): traffic is plaintext")
 	}
 
 	// Prometheus metrics & healthz HTTP endpoint
 	go func() {
 		logger.WithField("metrics_port", 2112).Info("Starting Prometheus metrics endpoint at /metrics and health check at /healthz")
-		http.Handle("/metrics", promhttp.Handler())
-		http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-			if mainDB != nil {
-				if err := mainDB.Ping(); err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte("db error: " + err.Error()))
-					return
-				}
-			}
-			if checkExternalHealth {
-				ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-				defer cancel()
-				// Assuming globalLocker is defined elsewhere or needs to be passed
-				// For now, we'll check if the locker is initialized and healthy
-				if globalLocker != nil { // Assuming globalLocker is the DistributedLocker
-					if err := globalLocker.HealthCheck(ctx); err != nil {
-						w.WriteHeader(http.StatusInternalServerError)
-						w.Write([]byte("external error: " + err.Error()))
-						return
-					}
-				}
-			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("ok"))
-		})
-		http.ListenAndServe(":2112", nil)
+		if err := newMetricsServer(":2112").ListenAndServe(); err != nil {
+			logger.WithError(err).Error("Metrics HTTP server stopped")
+		}
 	}()
 
-	// gRPC Prometheus interceptors
+	// Interceptor chain, assembled in the order documented in interceptors.go
 	grpcMetrics := grpc_prometheus.NewServerMetrics()
-	s := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-	)
+	if logPayloads {
+		logger.WithField("log_payload_format", payloadFormat).Info("Payload logging enabled (visible at LOG_LEVEL=debug)")
+	}
+	stages := serverInterceptorStages()
+	stageNames := make([]string, len(stages))
+	for i, st := range stages {
+		stageNames[i] = st.name
+	}
+	logger.WithField("interceptors", stageNames).Info("gRPC interceptor chain configured")
+
+	opts := append(interceptorOptions(stages), grpc.MaxSendMsgSize(maxSendMsgSize))
+	opts = append(opts, recvSizeServerOptions()...)
+	if tlsOpt != nil {
+		opts = append(opts, tlsOpt)
+	}
+
+	// Connection recycling so load balancers can rebalance long-lived clients
+	if params, ok, err := keepaliveServerParameters(); err != nil {
+		logger.WithError(err).Error("Invalid keepalive configuration")
+		return err
+	} else if ok {
+		opts = append(opts, grpc.KeepaliveParams(params))
+		logger.WithFields(logrus.Fields{
+			"max_connection_idle":      params.MaxConnectionIdle.String(),
+			"max_connection_age":       params.MaxConnectionAge.String(),
+			"max_connection_age_grace": params.MaxConnectionAgeGrace.String(),
+		}).Info("gRPC connection keepalive limits enabled")
+	}
+
+	s := grpc.NewServer(opts...)
 	grpcMetrics.InitializeMetrics(s)
 
-	pb.RegisterUserServiceServer(s, NewUserServer(mysqlDSN, lockType, redisAddr, etcdEndpoints))
+	userServer, err := NewUserServer(mysqlDSN, lockType, redisAddr, etcdEndpoints)
+	if err != nil {
+		logger.WithError(err).Error("Failed to initialize UserServer")
+		return err
+	}
+	pb.RegisterUserServiceServer(s, userServer)
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	// Standard gRPC health service (grpc.health.v1.Health)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	addr := listenAddress(os.Getenv("LISTEN_ADDR"), port)
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
-		logger.WithError(err).WithField("port", port).Error("Failed to listen on port")
+		logger.WithError(err).WithField("listen_addr", addr).Error("Failed to listen on port")
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
-	logger.WithField("port", port).Info("gRPC server listening")
-	return s.Serve(lis)
+	// SIGTERM/SIGINT: drain, then stop gracefully; Serve returns once stopped
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+		received := <-sig
+		logger.WithField("signal", received.String()).Info("Shutdown signal received")
+		drainAndStop(s, healthServer, userServer.events.close, shutdownDrainDelay, shutdownStopTimeout)
+	}()
+
+	logger.WithField("listen_addr", lis.Addr().String()).Info("gRPC server listening")
+	err = s.Serve(lis)
+	closeLocker(globalLocker)
+	userServer.close()
+	return err
 }
@@ -1,15 +1,41 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go-grpc-server-client/internal/redact"
 	pb "go-grpc-server-client/proto"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // MockDistributedLocker is a mock implementation of DistributedLocker
@@ -27,11 +53,62 @@ func (m *MockDistributedLocker) LockUser(ctx context.Context, userID int32) (Unl
 	return UnlockFunc(unlockFunc), args.Error(1)
 }
 
+func (m *MockDistributedLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	args := m.Called(ctx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	unlockFunc := args.Get(0).(func())
+	return UnlockFunc(unlockFunc), args.Error(1)
+}
+
 // Satisfy DistributedLocker interface
 func (m *MockDistributedLocker) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// memoryLocker is an in-process DistributedLocker with one non-reentrant
+// mutex per user, used to exercise real blocking behaviour in tests
+type memoryLocker struct {
+	mu       sync.Mutex
+	locks    map[int32]chan struct{}
+	acquired []int32 // acquisition order
+}
+
+func newMemoryLocker() *memoryLocker {
+	return &memoryLocker{locks: make(map[int32]chan struct{})}
+}
+
+func (m *memoryLocker) LockUser(ctx context.Context, userID int32) (UnlockFunc, error) {
+	m.mu.Lock()
+	ch, ok := m.locks[userID]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		m.locks[userID] = ch
+	}
+	m.mu.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	m.acquired = append(m.acquired, userID)
+	m.mu.Unlock()
+
+	return func() { <-ch }, nil
+}
+
+func (m *memoryLocker) LockUsers(ctx context.Context, userIDs []int32) (UnlockFunc, error) {
+	return lockUsersInOrder(ctx, m.LockUser, userIDs)
+}
+
+func (m *memoryLocker) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
 // MockDB is a mock implementation of database operations
 type MockDB struct {
 	mock.Mock
@@ -117,21 +194,6 @@ func TestUserServer_CreateUser(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
-		{
-			name: "failed to get last insert ID",
-			req: &pb.CreateUserRequest{
-				Name:  "John Doe",
-				Email: "john@example.com",
-				Age:   30,
-			},
-			setup: func(locker *MockDistributedLocker, db *MockDB) {
-				result := &MockResult{}
-				result.On("LastInsertId").Return(int64(0), fmt.Errorf("failed to get last insert ID"))
-				db.On("ExecContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(result, nil)
-			},
-			want:    nil,
-			wantErr: true,
-		},
 	}
 
 	for _, tt := range tests {
@@ -250,6 +312,155 @@ func TestEtcdLocker_LockUser(t *testing.T) {
 	t.Skip("Requires etcd instance")
 }
 
+// recordingLease captures the TTL of lease grants and fails them, which is
+// enough to observe how EtcdLocker configures its sessions without an etcd server
+type recordingLease struct {
+	clientv3.Lease
+	grantedTTL int64
+}
+
+func (l *recordingLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	l.grantedTTL = ttl
+	return nil, fmt.Errorf("grant refused by test")
+}
+
+func TestEtcdLocker_SessionTTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		sessionTTL int
+		wantTTL    int64
+	}{
+		{name: "etcd default", sessionTTL: 0, wantTTL: 60},
+		{name: "configured", sessionTTL: 10, wantTTL: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &recordingLease{}
+			cli := clientv3.NewCtxClient(context.Background())
+			cli.Lease = lease
+			locker := &EtcdLocker{client: cli, sessionTTL: tt.sessionTTL}
+
+			_, err := locker.LockUser(context.Background(), 1)
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantTTL, lease.grantedTTL)
+		})
+	}
+}
+
+func TestLockUsersInOrder(t *testing.T) {
+	t.Run("sorted and deduplicated", func(t *testing.T) {
+		locker := newMemoryLocker()
+
+		unlock, err := locker.LockUsers(context.Background(), []int32{3, 1, 2, 3})
+		require.NoError(t, err)
+		unlock()
+
+		assert.Equal(t, []int32{1, 2, 3}, locker.acquired)
+	})
+
+	t.Run("releases in reverse order", func(t *testing.T) {
+		var released []int32
+		lockUser := func(ctx context.Context, id int32) (UnlockFunc, error) {
+			return func() { released = append(released, id) }, nil
+		}
+
+		unlock, err := lockUsersInOrder(context.Background(), lockUser, []int32{2, 1, 3})
+		require.NoError(t, err)
+		unlock()
+
+		assert.Equal(t, []int32{3, 2, 1}, released)
+	})
+
+	t.Run("releases acquired locks on failure", func(t *testing.T) {
+		var released []int32
+		lockUser := func(ctx context.Context, id int32) (UnlockFunc, error) {
+			if id == 3 {
+				return nil, fmt.Errorf("lock acquisition failed")
+			}
+			return func() { released = append(released, id) }, nil
+		}
+
+		unlock, err := lockUsersInOrder(context.Background(), lockUser, []int32{3, 1, 2})
+		assert.Error(t, err)
+		assert.Nil(t, unlock)
+		assert.Equal(t, []int32{2, 1}, released)
+	})
+
+	t.Run("cross acquisition does not deadlock", func(t *testing.T) {
+		locker := newMemoryLocker()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Two kinds of bulk requests lock the same users in opposite order; with
+		// naive in-order locking they would eventually deadlock
+		var wg sync.WaitGroup
+		errs := make(chan error, 200)
+		for i := 0; i < 100; i++ {
+			for _, ids := range [][]int32{{1, 2}, {2, 1}} {
+				wg.Add(1)
+				go func(ids []int32) {
+					defer wg.Done()
+					unlock, err := locker.LockUsers(ctx, ids)
+					if err != nil {
+						errs <- err
+						return
+					}
+					time.Sleep(time.Microsecond)
+					unlock()
+				}(ids)
+			}
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Fatalf("bulk lock failed (deadlock?): %v", err)
+		}
+	})
+}
+
+func TestLockKeyPrefix(t *testing.T) {
+	t.Run("redis", func(t *testing.T) {
+		assert.Equal(t, "user-lock-1", (&RedsyncLocker{}).lockKey(1))
+		assert.Equal(t, "billing-prod:user-lock-1", (&RedsyncLocker{keyPrefix: "billing-prod"}).lockKey(1))
+
+		a := (&RedsyncLocker{keyPrefix: "svc-a"}).lockKey(1)
+		b := (&RedsyncLocker{keyPrefix: "svc-b"}).lockKey(1)
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("etcd", func(t *testing.T) {
+		assert.Equal(t, "/user-lock-1", (&EtcdLocker{}).lockKey(1))
+		assert.Equal(t, "/billing-prod/user-lock-1", (&EtcdLocker{keyPrefix: "billing-prod"}).lockKey(1))
+
+		a := (&EtcdLocker{keyPrefix: "svc-a"}).lockKey(1)
+		b := (&EtcdLocker{keyPrefix: "svc-b"}).lockKey(1)
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("prefixed locks do not contend", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+
+		defer func(prev string) { lockKeyPrefix = prev }(lockKeyPrefix)
+		lockKeyPrefix = "svc-a"
+		lockerA := NewRedsyncLocker(mr.Addr())
+		lockKeyPrefix = "svc-b"
+		lockerB := NewRedsyncLocker(mr.Addr())
+
+		unlockA, err := lockerA.LockUser(context.Background(), 1)
+		require.NoError(t, err)
+		defer unlockA()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		unlockB, err := lockerB.LockUser(ctx, 1)
+		require.NoError(t, err)
+		unlockB()
+	})
+}
+
 func TestUserServer_GetUser(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -454,3 +665,906 @@ func TestUserServer_UpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserServer_UpdateUsers(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRow := func(id int32, name, email string, age int32) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "age", "created_at", "updated_at"}).
+			AddRow(id, name, email, age, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z")
+	}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE users SET`).
+		WithArgs("Alice", "alice@example.com", 31, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(userRow(1, "Alice", "alice@example.com", 31))
+	sqlMock.ExpectExec(`UPDATE users SET`).
+		WithArgs("Ghost", "ghost@example.com", 40, sqlmock.AnyArg(), 999).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(`UPDATE users SET`).
+		WithArgs("Bob", "bob@example.com", 26, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(2).
+		WillReturnRows(userRow(2, "Bob", "bob@example.com", 26))
+	sqlMock.ExpectCommit()
+
+	locker := newMemoryLocker()
+	server := NewUserServerWithDB(db, locker)
+
+	got, err := server.UpdateUsers(context.Background(), &pb.UpdateUsersRequest{
+		Users: []*pb.UpdateUserRequest{
+			{Id: 1, Name: "Alice", Email: "alice@example.com", Age: 31},
+			{Id: 999, Name: "Ghost", Email: "ghost@example.com", Age: 40},
+			{Id: 2, Name: "Bob", Email: "bob@example.com", Age: 26},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.True(t, got.Success)
+	assert.Equal(t, int32(2), got.UpdatedCount)
+	assert.Equal(t, int32(1), got.NotFoundCount)
+
+	require.Len(t, got.Results, 3)
+	assert.Equal(t, int32(1), got.Results[0].Id)
+	assert.True(t, got.Results[0].Updated)
+	assert.Equal(t, "Alice", got.Results[0].User.Name)
+	assert.Equal(t, int32(999), got.Results[1].Id)
+	assert.False(t, got.Results[1].Updated)
+	assert.Nil(t, got.Results[1].User)
+	assert.Equal(t, int32(2), got.Results[2].Id)
+	assert.True(t, got.Results[2].Updated)
+	assert.Equal(t, "Bob", got.Results[2].User.Name)
+
+	assert.ElementsMatch(t, []int32{1, 2, 999}, locker.acquired)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_UpdateUsers_RollsBackOnError(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE users SET`).WillReturnError(fmt.Errorf("database error"))
+	sqlMock.ExpectRollback()
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	got, err := server.UpdateUsers(context.Background(), &pb.UpdateUsersRequest{
+		Users: []*pb.UpdateUserRequest{{Id: 1, Name: "Alice", Email: "alice@example.com", Age: 31}},
+	})
+
+	assert.Nil(t, got)
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_UpdateUsers_DuplicateEmail(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`UPDATE users SET`).WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry})
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectQuery(`SELECT id FROM users WHERE email = \?`).
+		WithArgs("taken@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	_, err = server.UpdateUsers(context.Background(), &pb.UpdateUsersRequest{
+		Users: []*pb.UpdateUserRequest{{Id: 1, Name: "Alice", Email: "taken@example.com", Age: 31}},
+	})
+
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, "7", st.Details()[0].(*errdetails.ResourceInfo).ResourceName)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_UpdateUsers_InvalidRequest(t *testing.T) {
+	locker := &MockDistributedLocker{}
+	server := NewUserServerWithDB(&MockDB{}, locker)
+
+	_, err := server.UpdateUsers(context.Background(), &pb.UpdateUsersRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.UpdateUsers(context.Background(), &pb.UpdateUsersRequest{
+		Users: []*pb.UpdateUserRequest{
+			{Id: 1, Name: "Alice", Email: "alice@example.com", Age: 31},
+			{Id: 2, Name: "", Email: "bob@example.com", Age: 26},
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	locker.AssertNotCalled(t, "LockUsers")
+}
+
+func TestUserServer_UpdateUser_DeletedBeforeReselect(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectExec(`UPDATE users SET`).
+		WithArgs("John Updated", "john.updated@example.com", 31, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Another process deleted the row before the re-SELECT
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "age", "created_at", "updated_at"}))
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+	got, err := server.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+		Id:    1,
+		Name:  "John Updated",
+		Email: "john.updated@example.com",
+		Age:   31,
+	})
+
+	assert.Nil(t, got)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "user was modified concurrently", st.Message())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_UpdateUser_DuplicateEmail(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectExec(`UPDATE users SET`).
+		WithArgs("John", "taken@example.com", 31, sqlmock.AnyArg(), 1).
+		WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry})
+	sqlMock.ExpectQuery(`SELECT id FROM users WHERE email = \?`).
+		WithArgs("taken@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+	got, err := server.UpdateUser(context.Background(), &pb.UpdateUserRequest{Id: 1, Name: "John", Email: "taken@example.com", Age: 31})
+
+	assert.Nil(t, got)
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, "7", st.Details()[0].(*errdetails.ResourceInfo).ResourceName)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_UpdateUser_SkipUnchanged(t *testing.T) {
+	t.Run("identical update does not write", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+
+		server := NewUserServerWithDB(db, newMemoryLocker())
+		got, err := server.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+			Id:            1,
+			Name:          "John Doe",
+			Email:         "john@example.com",
+			Age:           30,
+			SkipUnchanged: true,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, got.Success)
+		assert.Equal(t, "No changes", got.Message)
+		assert.Equal(t, "2023-01-01T00:00:00Z", got.User.UpdatedAt)
+		// sqlmock fails any UPDATE that was not expected
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("changed update writes", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z"))
+		sqlMock.ExpectExec(`UPDATE users SET`).
+			WithArgs("John Doe", "john@example.com", 31, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users WHERE id = \?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "John Doe", "john@example.com", 31, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z"))
+
+		server := NewUserServerWithDB(db, newMemoryLocker())
+		got, err := server.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+			Id:            1,
+			Name:          "John Doe",
+			Email:         "john@example.com",
+			Age:           31,
+			SkipUnchanged: true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "User updated successfully", got.Message)
+		assert.Equal(t, int32(31), got.User.Age)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+// fakeServerTransportStream captures response metadata for handlers that are
+// called directly instead of through a gRPC server
+type fakeServerTransportStream struct {
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *fakeServerTransportStream) Method() string { return "" }
+
+func (s *fakeServerTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *fakeServerTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *fakeServerTransportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestUserServer_ListUsers_ClampsLimit(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "age", "created_at", "updated_at"}).
+		AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).
+		WithArgs(maxPageLimit, 0).
+		WillReturnRows(rows)
+
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	stream := &fakeServerTransportStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	got, err := server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Limit: 10000})
+
+	require.NoError(t, err)
+	assert.True(t, got.Success)
+	assert.Len(t, got.Users, 1)
+	assert.Equal(t, []string{"500"}, stream.header.Get(pageLimitHeader))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ListUsers_OrderedByID(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "age", "created_at", "updated_at"}).
+		AddRow(1, "Alice", "alice@example.com", 28, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z").
+		AddRow(2, "Bob", "bob@example.com", 32, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z").
+		AddRow(5, "Charlie", "charlie@example.com", 29, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC LIMIT ? OFFSET ?`).
+		WithArgs(10, 0).
+		WillReturnRows(rows)
+
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+
+	require.NoError(t, err)
+	require.Len(t, got.Users, 3)
+	assert.True(t, sort.SliceIsSorted(got.Users, func(i, j int) bool {
+		return got.Users[i].Id < got.Users[j].Id
+	}))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_ListUsers_RowsErrorMidStream(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The connection drops after the first row
+	rows := sqlmock.NewRows(userColumns).
+		AddRow(1, "Alice", "alice@example.com", 28, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z").
+		AddRow(2, "Bob", "bob@example.com", 32, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z").
+		RowError(1, fmt.Errorf("connection reset by peer"))
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users ORDER BY id ASC LIMIT \? OFFSET \?`).
+		WithArgs(10, 0).
+		WillReturnRows(rows)
+
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+	got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+
+	assert.ErrorContains(t, err, "connection reset by peer")
+	assert.Nil(t, got, "a truncated page must not be returned as success")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestEffectivePage(t *testing.T) {
+	tests := []struct {
+		name             string
+		page, limit      int32
+		wantPage, wantLm int32
+	}{
+		{name: "defaults", page: 0, limit: 0, wantPage: 1, wantLm: defaultPageLimit},
+		{name: "within bounds", page: 3, limit: 20, wantPage: 3, wantLm: 20},
+		{name: "clamped to max", page: 1, limit: 10000, wantPage: 1, wantLm: maxPageLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit := effectivePage(tt.page, tt.limit)
+			assert.Equal(t, tt.wantPage, page)
+			assert.Equal(t, tt.wantLm, limit)
+		})
+	}
+}
+
+func TestPageOffset(t *testing.T) {
+	offset, err := pageOffset(3, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int32(40), offset)
+
+	// (page-1)*limit would wrap around in int32
+	_, err = pageOffset(math.MaxInt32, maxPageLimit)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserServer_ListUsers_PageOutOfRange(t *testing.T) {
+	// No query is expected: the request is rejected before touching the database
+	server := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+
+	_, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: math.MaxInt32, Limit: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.ListUserIDs(context.Background(), &pb.ListUserIDsRequest{Page: math.MaxInt32, Limit: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserServer_CreateUser_LastInsertIdUnsupported(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectExec(`INSERT INTO users`).
+		WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("LastInsertId is not supported by this driver")))
+	sqlMock.ExpectQuery(`SELECT id FROM users WHERE email = \?`).
+		WithArgs("john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+	got, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	require.NoError(t, err)
+	assert.True(t, got.Success)
+	assert.Equal(t, int32(42), got.User.Id)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserServer_CreateUser_LastInsertIdFallbackFails(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectExec(`INSERT INTO users`).
+		WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("failed to get last insert ID")))
+	sqlMock.ExpectQuery(`SELECT id FROM users WHERE email = \?`).
+		WillReturnError(fmt.Errorf("database connection failed"))
+
+	server := NewUserServerWithDB(db, newMemoryLocker())
+	got, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+
+	assert.Nil(t, got)
+	assert.ErrorContains(t, err, "failed to get last insert ID")
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+// uniqueEmailDB emulates the unique index on users.email for inserts and
+// answers the lookup of the user holding an email through a sqlmock database
+type uniqueEmailDB struct {
+	DBInterface
+	mu      sync.Mutex
+	emails  map[string]int64
+	lookup  *sql.DB
+	sqlMock sqlmock.Sqlmock
+}
+
+func newUniqueEmailDB(t *testing.T) *uniqueEmailDB {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return &uniqueEmailDB{emails: make(map[string]int64), lookup: db, sqlMock: sqlMock}
+}
+
+func (d *uniqueEmailDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	email := args[1].(string)
+	if _, ok := d.emails[email]; ok {
+		return nil, &mysql.MySQLError{Number: mysqlErrDupEntry, Message: "Duplicate entry '" + email + "' for key 'users.email'"}
+	}
+	id := int64(len(d.emails) + 1)
+	d.emails[email] = id
+	return sqlmock.NewResult(id, 1), nil
+}
+
+func (d *uniqueEmailDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sqlMock.ExpectQuery(`SELECT id FROM users WHERE email = \?`).
+		WithArgs(args[0]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(d.emails[args[0].(string)]))
+	return d.lookup.QueryRowContext(ctx, query, args...)
+}
+
+func TestUserServer_CreateUser_ConcurrentDuplicateEmail(t *testing.T) {
+	// The locker has no expectations: CreateUser must not touch it
+	locker := &MockDistributedLocker{}
+	server := NewUserServerWithDB(newUniqueEmailDB(t), locker)
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = server.CreateUser(context.Background(), &pb.CreateUserRequest{
+				Name:  fmt.Sprintf("User %d", i),
+				Email: "same@example.com",
+				Age:   30,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.AlreadyExists, st.Code())
+		assert.Equal(t, "email already exists", st.Message())
+	}
+	assert.Equal(t, 1, succeeded)
+	locker.AssertExpectations(t)
+}
+
+func TestUserServer_CreateUser_DuplicateEmailNamesExistingUser(t *testing.T) {
+	db := newUniqueEmailDB(t)
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	for _, email := range []string{"first@example.com", "taken@example.com"} {
+		_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Existing", Email: email, Age: 30})
+		require.NoError(t, err)
+	}
+
+	_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "Newcomer", Email: "taken@example.com", Age: 25})
+
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ResourceInfo)
+	require.True(t, ok)
+	assert.Equal(t, "user", info.ResourceType)
+	assert.Equal(t, "2", info.ResourceName)
+	assert.Equal(t, "email already belongs to user 2", info.Description)
+}
+
+func TestUserServer_CreateUser_RedactsEmailInLogs(t *testing.T) {
+	defer redact.SetEnabled(redact.Enabled())
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(os.Stderr)
+
+	for _, tt := range []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "redaction on", enabled: true, want: "j***@example.com"},
+		{name: "redaction off", enabled: false, want: "john@example.com"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			redact.SetEnabled(tt.enabled)
+			buf.Reset()
+
+			result := &MockResult{}
+			result.On("LastInsertId").Return(int64(1), nil)
+			db := &MockDB{}
+			db.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(result, nil)
+
+			server := NewUserServerWithDB(db, &MockDistributedLocker{})
+			_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{
+				Name:  "John Doe",
+				Email: "john@example.com",
+				Age:   30,
+			})
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.NotEmpty(t, lines)
+			for _, line := range lines {
+				var entry map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+				if email, ok := entry["user_email"]; ok {
+					assert.Equal(t, tt.want, email)
+				}
+			}
+			if !tt.enabled {
+				return
+			}
+			assert.NotContains(t, buf.String(), "john@example.com")
+		})
+	}
+}
+
+func TestUserServer_ListUsers_ETag(t *testing.T) {
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "age", "created_at", "updated_at"}).
+			AddRow(1, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z").
+			AddRow(2, "Jane Smith", "jane@example.com", 25, "2023-01-01T00:00:00Z", "2023-01-02T00:00:00Z")
+	}
+
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+	first, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.Etag)
+	assert.False(t, first.NotModified)
+	assert.Len(t, first.Users, 2)
+
+	t.Run("matching etag", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+		got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10, IfNoneMatch: first.Etag})
+		require.NoError(t, err)
+		assert.True(t, got.Success)
+		assert.True(t, got.NotModified)
+		assert.Empty(t, got.Users)
+		assert.Equal(t, first.Etag, got.Etag)
+	})
+
+	t.Run("non-matching etag", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(newRows())
+		got, err := server.ListUsers(context.Background(), &pb.ListUsersRequest{Page: 1, Limit: 10, IfNoneMatch: "stale"})
+		require.NoError(t, err)
+		assert.False(t, got.NotModified)
+		assert.Len(t, got.Users, 2)
+		assert.Equal(t, first.Etag, got.Etag)
+	})
+
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUsersETag(t *testing.T) {
+	a := &pb.User{Id: 1, UpdatedAt: "2023-01-01T00:00:00Z"}
+	b := &pb.User{Id: 2, UpdatedAt: "2023-01-01T00:00:00Z"}
+	bUpdated := &pb.User{Id: 2, UpdatedAt: "2023-01-02T00:00:00Z"}
+
+	assert.Equal(t, usersETag([]*pb.User{a, b}), usersETag([]*pb.User{b, a}), "etag must not depend on row order")
+	assert.NotEqual(t, usersETag([]*pb.User{a, b}), usersETag([]*pb.User{a, bUpdated}), "etag must change when a user is updated")
+	assert.NotEqual(t, usersETag([]*pb.User{a, b}), usersETag([]*pb.User{a}), "etag must change when a user is removed")
+}
+
+func TestRedisOptions(t *testing.T) {
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("REDIS_PASSWORD", "s3cret")
+		t.Setenv("REDIS_DB", "2")
+
+		opts, err := redisOptions("localhost:6379")
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:6379", opts.Addr)
+		assert.Equal(t, "s3cret", opts.Password)
+		assert.Equal(t, 2, opts.DB)
+	})
+
+	t.Run("from URL", func(t *testing.T) {
+		opts, err := redisOptions("redis://:urlpass@redis.internal:6380/3")
+		require.NoError(t, err)
+		assert.Equal(t, "redis.internal:6380", opts.Addr)
+		assert.Equal(t, "urlpass", opts.Password)
+		assert.Equal(t, 3, opts.DB)
+	})
+
+	t.Run("invalid DB index", func(t *testing.T) {
+		t.Setenv("REDIS_DB", "two")
+
+		_, err := redisOptions("localhost:6379")
+		assert.Error(t, err)
+	})
+
+	t.Run("connects with password", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		mr.RequireAuth("s3cret")
+		t.Setenv("REDIS_PASSWORD", "s3cret")
+
+		locker := NewRedsyncLocker(mr.Addr())
+		assert.NoError(t, locker.HealthCheck(context.Background()))
+	})
+}
+
+func TestRedsyncLocker_LockRetries(t *testing.T) {
+	defer func(n int, d time.Duration) { lockRetries, lockRetryDelay = n, d }(lockRetries, lockRetryDelay)
+
+	t.Run("acquires after holder releases within retry window", func(t *testing.T) {
+		lockRetries, lockRetryDelay = 20, 20*time.Millisecond
+		mr := miniredis.RunT(t)
+		locker := NewRedsyncLocker(mr.Addr())
+
+		unlock, err := locker.LockUser(context.Background(), 1)
+		require.NoError(t, err)
+		time.AfterFunc(100*time.Millisecond, unlock)
+
+		start := time.Now()
+		unlock2, err := locker.LockUser(context.Background(), 1)
+		require.NoError(t, err)
+		unlock2()
+		assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	})
+
+	t.Run("gives up when retries run out", func(t *testing.T) {
+		lockRetries, lockRetryDelay = 2, 10*time.Millisecond
+		mr := miniredis.RunT(t)
+		locker := NewRedsyncLocker(mr.Addr())
+
+		unlock, err := locker.LockUser(context.Background(), 1)
+		require.NoError(t, err)
+		defer unlock()
+
+		_, err = locker.LockUser(context.Background(), 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestCloseLocker_ClosesRedisClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+	locker := NewRedsyncLocker(mr.Addr())
+	require.NoError(t, locker.HealthCheck(context.Background()))
+
+	closeLocker(newBreakerLocker(locker, 5, time.Second))
+
+	assert.ErrorIs(t, locker.HealthCheck(context.Background()), redis.ErrClosed)
+}
+
+func TestMaskRedisAddr(t *testing.T) {
+	assert.Equal(t, "localhost:6379", maskRedisAddr("localhost:6379"))
+	assert.NotContains(t, maskRedisAddr("redis://:s3cret@localhost:6379/0"), "s3cret")
+}
+
+func TestListenAddress(t *testing.T) {
+	assert.Equal(t, ":50051", listenAddress("", 50051))
+	assert.Equal(t, "127.0.0.1:50051", listenAddress("127.0.0.1", 50051))
+	assert.Equal(t, "0.0.0.0:50051", listenAddress("0.0.0.0", 50051))
+	assert.Equal(t, "[::1]:50051", listenAddress("::1", 50051))
+}
+
+func TestListenAddress_LoopbackOnly(t *testing.T) {
+	lis, err := net.Listen("tcp", listenAddress("127.0.0.1", 0))
+	require.NoError(t, err)
+	defer lis.Close()
+
+	addr := lis.Addr().(*net.TCPAddr)
+	assert.True(t, addr.IP.IsLoopback(), "expected loopback bind, got %s", addr.IP)
+
+	// Dialing the port through any non-loopback interface address must fail
+	ifaceAddrs, err := net.InterfaceAddrs()
+	require.NoError(t, err)
+	for _, a := range ifaceAddrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		target := net.JoinHostPort(ipNet.IP.String(), strconv.Itoa(addr.Port))
+		conn, err := net.DialTimeout("tcp", target, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			t.Errorf("server bound to loopback was reachable via %s", target)
+		}
+	}
+}
+
+func TestKeepaliveServerParameters(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		_, ok, err := keepaliveServerParameters()
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("GRPC_MAX_CONNECTION_IDLE", "5m")
+		t.Setenv("GRPC_MAX_CONNECTION_AGE", "30m")
+		t.Setenv("GRPC_MAX_CONNECTION_AGE_GRACE", "10s")
+
+		params, ok, err := keepaliveServerParameters()
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 5*time.Minute, params.MaxConnectionIdle)
+		assert.Equal(t, 30*time.Minute, params.MaxConnectionAge)
+		assert.Equal(t, 10*time.Second, params.MaxConnectionAgeGrace)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("GRPC_MAX_CONNECTION_AGE", "soon")
+
+		_, _, err := keepaliveServerParameters()
+		assert.Error(t, err)
+	})
+}
+
+func TestKeepaliveServerParameters_RecyclesConnections(t *testing.T) {
+	t.Setenv("GRPC_MAX_CONNECTION_AGE", "200ms")
+	t.Setenv("GRPC_MAX_CONNECTION_AGE_GRACE", "100ms")
+
+	params, ok, err := keepaliveServerParameters()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.KeepaliveParams(params))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, connectivity.Ready, conn.GetState())
+
+	// The server closes the connection once it exceeds MaxConnectionAge
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.True(t, conn.WaitForStateChange(ctx, connectivity.Ready), "connection was not recycled")
+}
+
+func TestNewUserServer_Misconfiguration(t *testing.T) {
+	const dsn = "user:password@tcp(localhost:3306)/dbname"
+	tests := []struct {
+		name          string
+		mysqlDSN      string
+		lockType      string
+		redisAddr     string
+		etcdEndpoints string
+		wantErr       string
+	}{
+		{name: "missing MySQL DSN", lockType: "redis", redisAddr: "localhost:6379", wantErr: "MYSQL_DSN required"},
+		{name: "missing lock type", mysqlDSN: dsn, wantErr: "LOCK_TYPE required (must be 'redis' or 'etcd')"},
+		{name: "unknown lock type", mysqlDSN: dsn, lockType: "zookeeper", wantErr: `unknown LOCK_TYPE "zookeeper" (must be 'redis' or 'etcd')`},
+		{name: "redis without address", mysqlDSN: dsn, lockType: "redis", etcdEndpoints: "localhost:2379", wantErr: "REDIS_ADDR required for LOCK_TYPE=redis"},
+		{name: "etcd without endpoints", mysqlDSN: dsn, lockType: "etcd", redisAddr: "localhost:6379", wantErr: "ETCD_ENDPOINTS required for LOCK_TYPE=etcd"},
+		{name: "upper-case lock type without address", mysqlDSN: dsn, lockType: "REDIS", wantErr: "REDIS_ADDR required for LOCK_TYPE=redis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewUserServer(tt.mysqlDSN, tt.lockType, tt.redisAddr, tt.etcdEndpoints)
+
+			assert.Nil(t, got)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestValidateServerConfig_Valid(t *testing.T) {
+	const dsn = "user:password@tcp(localhost:3306)/dbname"
+	assert.NoError(t, validateServerConfig(dsn, "redis", "localhost:6379", ""))
+	assert.NoError(t, validateServerConfig(dsn, "etcd", "", "localhost:2379"))
+}
+
+func TestUserServer_WithUserLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func() error
+		wantErr string
+	}{
+		{name: "success", fn: func() error { return nil }},
+		{name: "error", fn: func() error { return fmt.Errorf("database error") }, wantErr: "database error"},
+		{name: "status error", fn: func() error { return status.Error(codes.NotFound, "gone") }, wantErr: "gone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unlocked := 0
+			locker := &MockDistributedLocker{}
+			locker.On("LockUser", mock.Anything, int32(1)).Return(func() { unlocked++ }, nil)
+			server := NewUserServerWithDB(&MockDB{}, locker)
+
+			err := server.withUserLock(context.Background(), 1, func() error {
+				assert.Equal(t, 0, unlocked, "fn must run while the lock is held")
+				return tt.fn()
+			})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+			assert.Equal(t, 1, unlocked)
+		})
+	}
+
+	t.Run("lock failure", func(t *testing.T) {
+		locker := &MockDistributedLocker{}
+		locker.On("LockUser", mock.Anything, int32(1)).Return(nil, fmt.Errorf("lock acquisition failed"))
+		server := NewUserServerWithDB(&MockDB{}, locker)
+
+		called := false
+		err := server.withUserLock(context.Background(), 1, func() error {
+			called = true
+			return nil
+		})
+
+		assert.ErrorContains(t, err, "failed to acquire lock")
+		assert.False(t, called)
+	})
+}
+
+func TestUserServer_DeleteUser_UnlocksOnDatabaseError(t *testing.T) {
+	unlocked := false
+	locker := &MockDistributedLocker{}
+	locker.On("LockUser", mock.Anything, int32(1)).Return(func() { unlocked = true }, nil)
+	db := &MockDB{}
+	db.On("ExecContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("database error"))
+	server := NewUserServerWithDB(db, locker)
+
+	_, err := server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1})
+
+	assert.Error(t, err)
+	assert.True(t, unlocked)
+}
+
+// cancelAfterQueryDB cancels the request context once QueryContext has
+// returned its rows, as if the client went away while they were being scanned
+type cancelAfterQueryDB struct {
+	DBInterface
+	cancel context.CancelFunc
+}
+
+func (d cancelAfterQueryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := d.DBInterface.QueryContext(ctx, query, args...)
+	d.cancel()
+	return rows, err
+}
+
+func TestUserServer_ListUsers_StopsScanOnCancel(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows(userColumns)
+	for id := 1; id <= 100; id++ {
+		rows.AddRow(id, "John Doe", "john@example.com", 30, "2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z")
+	}
+	sqlMock.ExpectQuery(`SELECT id, name, email, age, created_at, updated_at FROM users`).WillReturnRows(rows).RowsWillBeClosed()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := NewUserServerWithDB(cancelAfterQueryDB{DBInterface: db, cancel: cancel}, &MockDistributedLocker{})
+
+	got, err := server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, Limit: 100})
+
+	assert.Nil(t, got)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+
+	pb "go-grpc-server-client/proto"
+)
+
+// Build information, injected at build time with
+// -ldflags "-X go-grpc-server-client/internal/server.Version=..."
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+	GitCommit = "unknown"
+)
+
+// ServerInfo reports the server build and the active lock type, so clients
+// and operators can tell which build they are talking to
+func (s *UserServer) ServerInfo(ctx context.Context, req *pb.ServerInfoRequest) (*pb.ServerInfoResponse, error) {
+	return &pb.ServerInfoResponse{
+		Version:   Version,
+		BuildTime: BuildTime,
+		GitCommit: GitCommit,
+		LockType:  s.lockType,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserServer_ServerInfo(t *testing.T) {
+	defer func(v, b, c string) { Version, BuildTime, GitCommit = v, b, c }(Version, BuildTime, GitCommit)
+	// Equivalent to -ldflags "-X ...server.Version=v1.2.3 ..."
+	Version, BuildTime, GitCommit = "v1.2.3", "2024-01-01T00:00:00Z", "abc1234"
+
+	server := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+	server.lockType = lockTypeEtcd
+
+	resp, err := server.ServerInfo(context.Background(), &pb.ServerInfoRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", resp.Version)
+	assert.Equal(t, "2024-01-01T00:00:00Z", resp.BuildTime)
+	assert.Equal(t, "abc1234", resp.GitCommit)
+	assert.Equal(t, "etcd", resp.LockType)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+)
+
+// shutdownDrainDelay is how long the server keeps serving after SIGTERM while
+// reporting NOT_SERVING, giving load balancers time to stop routing to it
+// before GracefulStop (SHUTDOWN_DRAIN_DELAY)
+var shutdownDrainDelay time.Duration
+
+// shutdownStopTimeout bounds how long GracefulStop may wait for in-flight RPCs
+// before the server is stopped forcibly (SHUTDOWN_STOP_TIMEOUT)
+var shutdownStopTimeout = 30 * time.Second
+
+// draining is set once shutdown has begun; /healthz then answers 503
+var draining atomic.Bool
+
+// gracefulStopper is the part of *grpc.Server used during shutdown
+type gracefulStopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// drainAndStop fails health checks, waits for delay while still serving
+// RPCs, then stops accepting new RPCs and waits for in-flight ones to finish.
+// closeStreams is called first so that long-lived streams (WatchUsers) end
+// instead of holding GracefulStop open; anything still running after
+// stopTimeout is cut off with Stop.
+func drainAndStop(s gracefulStopper, healthServer *health.Server, closeStreams func(), delay, stopTimeout time.Duration) {
+	draining.Store(true)
+	if healthServer != nil {
+		healthServer.Shutdown()
+	}
+
+	if delay > 0 {
+		logger.WithField("drain_delay", delay.String()).Info("Draining: reporting NOT_SERVING before stopping")
+		time.Sleep(delay)
+	}
+
+	if closeStreams != nil {
+		closeStreams()
+	}
+
+	logger.Info("Stopping gRPC server gracefully")
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		logger.WithField("stop_timeout", stopTimeout.String()).Warn("Graceful stop timed out, closing remaining connections")
+		s.Stop()
+		<-stopped
+	}
+	logger.Info("gRPC server stopped")
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"go-grpc-server-client/internal/testutil"
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// startTLSServer serves an empty UserService with the given TLS config and
+// returns its address
+func startTLSServer(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	pb.RegisterUserServiceServer(s, &pb.UnimplementedUserServiceServer{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String()
+}
+
+func callWithTLS(t *testing.T, addr string, tlsConfig *tls.Config) error {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = pb.NewUserServiceClient(conn).GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	return err
+}
+
+func TestServerTLSConfig_MutualTLS(t *testing.T) {
+	certs := testutil.WriteTestCerts(t)
+
+	serverConfig, err := serverTLSConfig(certs.ServerCertFile, certs.ServerKeyFile, certs.CAFile)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+	addr := startTLSServer(t, serverConfig)
+
+	roots, err := loadCertPool(certs.CAFile)
+	require.NoError(t, err)
+
+	t.Run("client with matching certificate", func(t *testing.T) {
+		clientCert, err := tls.LoadX509KeyPair(certs.ClientCertFile, certs.ClientKeyFile)
+		require.NoError(t, err)
+
+		err = callWithTLS(t, addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}})
+		// The handshake succeeded, so the call reaches the (unimplemented) handler
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("client without certificate", func(t *testing.T) {
+		err := callWithTLS(t, addr, &tls.Config{RootCAs: roots})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestServerTLSConfig_ServerOnly(t *testing.T) {
+	certs := testutil.WriteTestCerts(t)
+
+	cfg, err := serverTLSConfig(certs.ServerCertFile, certs.ServerKeyFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+
+	_, err = serverTLSConfig(certs.ServerCertFile, certs.ServerKeyFile, certs.ServerKeyFile)
+	assert.Error(t, err)
+}
+
+func TestServerTLSConfig_MinVersion(t *testing.T) {
+	certs := testutil.WriteTestCerts(t)
+	roots, err := loadCertPool(certs.CAFile)
+	require.NoError(t, err)
+
+	cfg, err := serverTLSConfig(certs.ServerCertFile, certs.ServerKeyFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	addr := startTLSServer(t, cfg)
+
+	t.Run("TLS 1.1 is rejected", func(t *testing.T) {
+		err := callWithTLS(t, addr, &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS11, MaxVersion: tls.VersionTLS11})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("TLS 1.2 is accepted", func(t *testing.T) {
+		err := callWithTLS(t, addr, &tls.Config{RootCAs: roots, MaxVersion: tls.VersionTLS12})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestEtcdConfig(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		cfg, err := etcdConfig([]string{"localhost:2379"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"localhost:2379"}, cfg.Endpoints)
+		assert.Empty(t, cfg.Username)
+		assert.Nil(t, cfg.TLS)
+	})
+
+	t.Run("auth and TLS from env", func(t *testing.T) {
+		certs := testutil.WriteTestCerts(t)
+		t.Setenv("ETCD_USERNAME", "root")
+		t.Setenv("ETCD_PASSWORD", "s3cret")
+		t.Setenv("ETCD_TLS_CA_FILE", certs.CAFile)
+		t.Setenv("ETCD_TLS_CERT_FILE", certs.ClientCertFile)
+		t.Setenv("ETCD_TLS_KEY_FILE", certs.ClientKeyFile)
+
+		cfg, err := etcdConfig([]string{"etcd-1:2379", "etcd-2:2379"})
+		require.NoError(t, err)
+		assert.Equal(t, "root", cfg.Username)
+		assert.Equal(t, "s3cret", cfg.Password)
+		require.NotNil(t, cfg.TLS)
+		assert.NotNil(t, cfg.TLS.RootCAs)
+		assert.Len(t, cfg.TLS.Certificates, 1)
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		t.Setenv("ETCD_TLS_CA_FILE", "/nonexistent/ca.pem")
+
+		_, err := etcdConfig([]string{"localhost:2379"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRunServer_RequiresTLSOrAllowInsecure(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "")
+	t.Setenv("TLS_KEY_FILE", "")
+	t.Setenv("CLIENT_CA_FILE", "")
+	t.Setenv("ALLOW_INSECURE", "")
+
+	err := RunServer(0)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refusing to start without TLS")
+	assert.Contains(t, err.Error(), "ALLOW_INSECURE=on")
+}
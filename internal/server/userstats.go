@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxAgeBuckets bounds the number of buckets, and so the size of the CASE
+// expression, a single UserStats request can ask for
+const maxAgeBuckets = 50
+
+// defaultAgeBounds splits ages into decades when a request names no bounds
+var defaultAgeBounds = []int32{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+
+// ageBuckets validates the lower bounds of all but the first bucket and
+// returns the buckets they describe, covering minAge through maxAge
+func ageBuckets(bounds []int32) ([]*pb.AgeBucket, error) {
+	if len(bounds) == 0 {
+		bounds = defaultAgeBounds
+	}
+	if len(bounds) >= maxAgeBuckets {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d age bounds are allowed", maxAgeBuckets-1)
+	}
+
+	buckets := make([]*pb.AgeBucket, 0, len(bounds)+1)
+	lower := int32(minAge)
+	for _, b := range bounds {
+		if b <= lower || b > maxAge {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"age bounds must be strictly increasing and between %d and %d", minAge+1, maxAge)
+		}
+		buckets = append(buckets, &pb.AgeBucket{MinAge: lower, MaxAge: b - 1})
+		lower = b
+	}
+	return append(buckets, &pb.AgeBucket{MinAge: lower, MaxAge: maxAge}), nil
+}
+
+// ageBucketQuery builds the GROUP BY query counting users per bucket. Each
+// row carries the bucket's index in buckets.
+func ageBucketQuery(buckets []*pb.AgeBucket) (string, []interface{}) {
+	var b strings.Builder
+	args := make([]interface{}, 0, len(buckets)-1)
+	b.WriteString(`SELECT CASE`)
+	for i, bucket := range buckets[:len(buckets)-1] {
+		fmt.Fprintf(&b, ` WHEN age <= ? THEN %d`, i)
+		args = append(args, bucket.MaxAge)
+	}
+	fmt.Fprintf(&b, ` ELSE %d END AS bucket, COUNT(*) FROM users GROUP BY bucket`, len(buckets)-1)
+	return b.String(), args
+}
+
+// UserStats counts users per age bucket and summarizes their ages. The
+// aggregation runs in the database, so no user rows are transferred.
+func (s *UserServer) UserStats(ctx context.Context, req *pb.UserStatsRequest) (*pb.UserStatsResponse, error) {
+	requestLog(ctx).WithField("age_bounds", req.AgeBounds).Info("UserStats request received")
+
+	buckets, err := ageBuckets(req.AgeBounds)
+	if err != nil {
+		requestLog(ctx).WithField("age_bounds", req.AgeBounds).Warn("Invalid UserStats age bounds")
+		return nil, err
+	}
+
+	dbCtx, cancel := withDBTimeout(ctx)
+	defer cancel()
+
+	resp := &pb.UserStatsResponse{Buckets: buckets}
+	done := timeDBQuery(dbOpList)
+	err = s.queryRowRead(dbCtx, func(row *sql.Row) error {
+		return row.Scan(&resp.Total, &resp.MinAge, &resp.MaxAge, &resp.AvgAge)
+	}, `SELECT COUNT(*), COALESCE(MIN(age), 0), COALESCE(MAX(age), 0), COALESCE(AVG(age), 0) FROM users`)
+	done()
+	if err != nil {
+		requestLog(ctx).WithError(err).Error("Database error summarizing ages in UserStats")
+		return nil, err
+	}
+
+	query, args := ageBucketQuery(buckets)
+	done = timeDBQuery(dbOpList)
+	rows, err := s.queryRead(dbCtx, query, args...)
+	done()
+	if err != nil {
+		requestLog(ctx).WithError(err).Error("Database error counting age buckets in UserStats")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var index int
+		var count int64
+		if err := rows.Scan(&index, &count); err != nil {
+			requestLog(ctx).WithError(err).Error("Error scanning age bucket in UserStats")
+			return nil, err
+		}
+		if index >= 0 && index < len(buckets) {
+			buckets[index].Count = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		requestLog(ctx).WithError(err).Error("Error iterating age buckets in UserStats")
+		return nil, err
+	}
+
+	requestLog(ctx).WithFields(logrus.Fields{
+		"total":   resp.Total,
+		"buckets": len(buckets),
+	}).Info("User stats computed")
+	return resp, nil
+}
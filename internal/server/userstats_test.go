@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserServer_UserStats(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewUserServerWithDB(db, newMemoryLocker())
+
+	sqlMock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(MIN\(age\), 0\), COALESCE\(MAX\(age\), 0\), COALESCE\(AVG\(age\), 0\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "min", "max", "avg"}).AddRow(6, 15, 72, 38.5))
+	// The empty middle bucket has no row in the GROUP BY result
+	sqlMock.ExpectQuery(`SELECT CASE WHEN age <= \? THEN 0 WHEN age <= \? THEN 1 ELSE 2 END AS bucket, COUNT\(\*\) FROM users GROUP BY bucket`).
+		WithArgs(19, 39).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(0, 2).AddRow(2, 4))
+
+	resp, err := server.UserStats(context.Background(), &pb.UserStatsRequest{AgeBounds: []int32{20, 40}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*pb.AgeBucket{
+		{MinAge: 0, MaxAge: 19, Count: 2},
+		{MinAge: 20, MaxAge: 39, Count: 0},
+		{MinAge: 40, MaxAge: 150, Count: 4},
+	}, resp.Buckets)
+	assert.Equal(t, int64(6), resp.Total)
+	assert.Equal(t, int32(15), resp.MinAge)
+	assert.Equal(t, int32(72), resp.MaxAge)
+	assert.Equal(t, 38.5, resp.AvgAge)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestAgeBuckets(t *testing.T) {
+	buckets, err := ageBuckets(nil)
+	require.NoError(t, err)
+	assert.Len(t, buckets, len(defaultAgeBounds)+1)
+	assert.Equal(t, &pb.AgeBucket{MinAge: 100, MaxAge: maxAge}, buckets[len(buckets)-1])
+
+	for _, bounds := range [][]int32{{30, 20}, {20, 20}, {0}, {maxAge + 1}, make([]int32, maxAgeBuckets)} {
+		_, err := ageBuckets(bounds)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "bounds %v", bounds)
+	}
+}
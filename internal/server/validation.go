@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Column sizes of the users table (VARCHAR(255))
+const (
+	maxNameLength  = 255
+	maxEmailLength = 255
+)
+
+// Accepted name and email lengths in characters (MAX_NAME_LEN, MAX_EMAIL_LEN).
+// They may be set below the column sizes but never above them.
+var (
+	maxNameLen  = maxNameLength
+	maxEmailLen = maxEmailLength
+)
+
+// Accepted age range
+const (
+	minAge = 0
+	maxAge = 150
+)
+
+// allowedEmailDomains restricts user emails to these domains
+// (ALLOWED_EMAIL_DOMAINS, comma-separated, lower case). Empty allows any domain.
+var allowedEmailDomains []string
+
+// parseEmailDomains splits a comma-separated domain list, ignoring blanks and
+// a leading "@"
+func parseEmailDomains(v string) []string {
+	var domains []string
+	for _, d := range strings.Split(v, ",") {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// validateUserFields checks user-supplied fields shared by CreateUser and
+// UpdateUser before any database work. Every problem found is reported as a
+// field violation in an errdetails.BadRequest attached to an InvalidArgument
+// status, so form-driven clients can map errors back to their inputs. Each
+// violation is also counted in validation_errors_total{field,reason}.
+func validateUserFields(name, email string, age int32) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	add := func(field, reason, description string) {
+		validationErrorsTotal.WithLabelValues(field, reason).Inc()
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	}
+
+	if strings.TrimSpace(name) == "" {
+		add("name", "required", "name is required")
+	} else if n := utf8.RuneCountInString(name); n > maxNameLen {
+		add("name", "too_long", fmt.Sprintf("name must be at most %d characters (got %d)", maxNameLen, n))
+	}
+
+	if email == "" {
+		add("email", "required", "email is required")
+	} else if n := utf8.RuneCountInString(email); n > maxEmailLen {
+		add("email", "too_long", fmt.Sprintf("email must be at most %d characters (got %d)", maxEmailLen, n))
+	} else if !isValidEmail(email) {
+		add("email", "invalid_format", "email is not a valid address")
+	} else if !isAllowedEmailDomain(email) {
+		add("email", "domain_not_allowed", "email domain is not allowed")
+	}
+
+	if age < minAge || age > maxAge {
+		add("age", "out_of_range", fmt.Sprintf("age must be between %d and %d", minAge, maxAge))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return invalidArgument(violations)
+}
+
+// isValidEmail accepts a bare address such as "john@example.com"
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// isAllowedEmailDomain reports whether email's domain is in allowedEmailDomains
+func isAllowedEmailDomain(email string) bool {
+	if len(allowedEmailDomains) == 0 {
+		return true
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	return slices.Contains(allowedEmailDomains, domain)
+}
+
+// invalidArgument builds an InvalidArgument status whose message lists every
+// violation and whose details carry them as an errdetails.BadRequest
+func invalidArgument(violations []*errdetails.BadRequest_FieldViolation) error {
+	descriptions := make([]string, len(violations))
+	for i, v := range violations {
+		descriptions[i] = v.Description
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, "; "))
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateUserFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		email   string
+		age     int32
+		wantErr string
+	}{
+		{name: "valid fields", user: "John Doe", email: "john@example.com", age: 30},
+		{name: "name at column limit", user: strings.Repeat("a", 255), email: "john@example.com", age: 30},
+		{name: "multibyte name at column limit", user: strings.Repeat("가", 255), email: "john@example.com", age: 30},
+		{name: "oversized name", user: strings.Repeat("a", 300), email: "john@example.com", age: 30, wantErr: "name must be at most 255 characters"},
+		{name: "oversized email", user: "John Doe", email: strings.Repeat("a", 300) + "@example.com", age: 30, wantErr: "email must be at most 255 characters"},
+		{name: "empty name", user: "  ", email: "john@example.com", age: 30, wantErr: "name is required"},
+		{name: "malformed email", user: "John Doe", email: "not-an-email", age: 30, wantErr: "email is not a valid address"},
+		{name: "negative age", user: "John Doe", email: "john@example.com", age: -1, wantErr: "age must be between 0 and 150"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUserFields(tt.user, tt.email, tt.age)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateUserFields_FieldViolations(t *testing.T) {
+	server := NewUserServerWithDB(&MockDB{}, &MockDistributedLocker{})
+
+	_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:  "",
+		Email: "john.example.com",
+		Age:   30,
+	})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, br.GetFieldViolations()...)
+		}
+	}
+
+	require.Len(t, violations, 2)
+	assert.Equal(t, "name", violations[0].GetField())
+	assert.Equal(t, "name is required", violations[0].GetDescription())
+	assert.Equal(t, "email", violations[1].GetField())
+	assert.Equal(t, "email is not a valid address", violations[1].GetDescription())
+}
+
+func TestUserServer_OversizedName(t *testing.T) {
+	longName := strings.Repeat("a", 300)
+
+	t.Run("create", func(t *testing.T) {
+		locker := &MockDistributedLocker{}
+		db := &MockDB{}
+		server := NewUserServerWithDB(db, locker)
+
+		got, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{
+			Name:  longName,
+			Email: "john@example.com",
+			Age:   30,
+		})
+
+		assert.Nil(t, got)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "name must be at most 255 characters")
+		db.AssertNotCalled(t, "ExecContext")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		locker := &MockDistributedLocker{}
+		db := &MockDB{}
+		server := NewUserServerWithDB(db, locker)
+
+		got, err := server.UpdateUser(context.Background(), &pb.UpdateUserRequest{
+			Id:    1,
+			Name:  longName,
+			Email: "john@example.com",
+			Age:   30,
+		})
+
+		assert.Nil(t, got)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		locker.AssertNotCalled(t, "LockUser")
+		db.AssertNotCalled(t, "ExecContext")
+	})
+}
+
+func TestValidateUserFields_AllowedEmailDomains(t *testing.T) {
+	defer func(d []string) { allowedEmailDomains = d }(allowedEmailDomains)
+	allowedEmailDomains = parseEmailDomains("example.com, @Corp.example.org")
+
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{name: "allowed domain", email: "john@example.com"},
+		{name: "allowed domain case-insensitive", email: "jane@CORP.example.org"},
+		{name: "disallowed domain", email: "john@gmail.com", wantErr: true},
+		{name: "subdomain not implied", email: "john@mail.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUserFields("John Doe", tt.email, 30)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Contains(t, err.Error(), "email domain is not allowed")
+		})
+	}
+
+	t.Run("create rejected before database work", func(t *testing.T) {
+		db := &MockDB{}
+		server := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+		_, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "John Doe", Email: "john@gmail.com", Age: 30})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		db.AssertNotCalled(t, "ExecContext")
+	})
+}
+
+func TestValidateUserFields_AnyDomainWhenUnset(t *testing.T) {
+	defer func(d []string) { allowedEmailDomains = d }(allowedEmailDomains)
+	allowedEmailDomains = parseEmailDomains("")
+
+	assert.NoError(t, validateUserFields("John Doe", "john@gmail.com", 30))
+}
+
+func TestValidateUserFields_ConfiguredMaxLengths(t *testing.T) {
+	defer func(name, email int) { maxNameLen, maxEmailLen = name, email }(maxNameLen, maxEmailLen)
+	maxNameLen, maxEmailLen = 20, 30
+
+	assert.NoError(t, validateUserFields(strings.Repeat("a", 20), "john@example.com", 30))
+
+	err := validateUserFields(strings.Repeat("a", 21), "john@example.com", 30)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "name must be at most 20 characters (got 21)")
+
+	err = validateUserFields("John Doe", strings.Repeat("a", 20)+"@example.com", 30)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "email must be at most 30 characters")
+}
+
+func TestUserServer_CreateUser_HugeNameRejectedBeforeDB(t *testing.T) {
+	defer func(n int) { maxNameLen = n }(maxNameLen)
+	maxNameLen = 64
+
+	db := &MockDB{}
+	server := NewUserServerWithDB(db, &MockDistributedLocker{})
+
+	got, err := server.CreateUser(context.Background(), &pb.CreateUserRequest{
+		Name:  strings.Repeat("a", 1<<20),
+		Email: "john@example.com",
+		Age:   30,
+	})
+
+	assert.Nil(t, got)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "name must be at most 64 characters")
+	db.AssertNotCalled(t, "ExecContext")
+}
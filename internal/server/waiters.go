@@ -0,0 +1,69 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxWaitersPerUser caps the requests queued for one user's lock; further
+// requests for that user are rejected with ResourceExhausted instead of
+// piling up (LOCK_MAX_WAITERS_PER_USER). Zero means no cap.
+var maxWaitersPerUser int
+
+// userWaiters counts the requests waiting for each user's lock. The zero
+// value is ready to use.
+type userWaiters struct {
+	mu     sync.Mutex
+	counts map[int32]int
+}
+
+// enter registers a waiter for every distinct user in userIDs, or for none of
+// them if any is already at maxWaitersPerUser. A user listed more than once
+// counts once, as LockUsers takes its lock only once. The returned func
+// unregisters them.
+func (w *userWaiters) enter(userIDs ...int32) (func(), error) {
+	if maxWaitersPerUser <= 0 {
+		return func() {}, nil
+	}
+
+	distinct := make([]int32, 0, len(userIDs))
+	seen := make(map[int32]bool, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+	userIDs = distinct
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range userIDs {
+		if w.counts[id] >= maxWaitersPerUser {
+			logger.WithFields(logrus.Fields{
+				"user_id": id,
+				"waiters": w.counts[id],
+			}).Warn("Too many requests waiting for user lock")
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests waiting for user %d", id)
+		}
+	}
+	if w.counts == nil {
+		w.counts = make(map[int32]int)
+	}
+	for _, id := range userIDs {
+		w.counts[id]++
+	}
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for _, id := range userIDs {
+			if w.counts[id]--; w.counts[id] <= 0 {
+				delete(w.counts, id)
+			}
+		}
+	}, nil
+}
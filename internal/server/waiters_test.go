@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go-grpc-server-client/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMaxWaitersPerUser(t *testing.T) {
+	defer func(n int) { maxWaitersPerUser = n }(maxWaitersPerUser)
+	maxWaitersPerUser = 2
+
+	locker := newMemoryLocker()
+	server := NewUserServerWithDB(&MockDB{}, locker)
+
+	// Hold the lock so the requests below have to wait for it
+	unlock, err := locker.LockUser(context.Background(), 1)
+	require.NoError(t, err)
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < maxWaitersPerUser; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1})
+			assert.Error(t, err)
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		server.waiters.mu.Lock()
+		defer server.waiters.mu.Unlock()
+		return server.waiters.counts[1] == maxWaitersPerUser
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Excess requests for the same user are rejected without queuing
+	start := time.Now()
+	_, err = server.DeleteUser(context.Background(), &pb.DeleteUserRequest{Id: 1})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Less(t, time.Since(start), time.Second)
+
+	cancel()
+	wg.Wait()
+
+	// Waiters are released once they give up
+	server.waiters.mu.Lock()
+	assert.Empty(t, server.waiters.counts)
+	server.waiters.mu.Unlock()
+}
+
+func TestUserWaiters_EnterIsAllOrNothing(t *testing.T) {
+	defer func(n int) { maxWaitersPerUser = n }(maxWaitersPerUser)
+	maxWaitersPerUser = 1
+
+	var w userWaiters
+	leave, err := w.enter(1)
+	require.NoError(t, err)
+
+	// User 1 is full, so user 2 must not be registered either
+	_, err = w.enter(2, 1)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, map[int32]int{1: 1}, w.counts)
+
+	leave()
+	leaveBoth, err := w.enter(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int{1: 1, 2: 1}, w.counts)
+	leaveBoth()
+	assert.Empty(t, w.counts)
+}
+
+func TestUserWaiters_EnterCountsDuplicatesOnce(t *testing.T) {
+	defer func(n int) { maxWaitersPerUser = n }(maxWaitersPerUser)
+	maxWaitersPerUser = 2
+
+	var w userWaiters
+	// One request repeating a user is a single waiter, not one per occurrence
+	leave, err := w.enter(1, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int{1: 1}, w.counts)
+
+	leaveOther, err := w.enter(1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int{1: 2}, w.counts)
+
+	leave()
+	leaveOther()
+	assert.Empty(t, w.counts)
+}
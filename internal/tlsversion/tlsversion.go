@@ -0,0 +1,23 @@
+// Package tlsversion parses the TLS_MIN_VERSION setting shared by the server
+// and client.
+package tlsversion
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// Parse parses a TLS version such as "1.2"
+func Parse(v string) (uint16, error) {
+	switch v {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q (must be 1.0, 1.1, 1.2 or 1.3)", v)
+}
@@ -0,0 +1,18 @@
+package tlsversion
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse("1.3")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), got)
+
+	_, err = Parse("TLS1.2")
+	assert.Error(t, err)
+}
@@ -21,6 +21,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type UserEvent_Type int32
+
+const (
+	UserEvent_TYPE_UNSPECIFIED UserEvent_Type = 0
+	UserEvent_CREATED          UserEvent_Type = 1
+	UserEvent_UPDATED          UserEvent_Type = 2
+	UserEvent_DELETED          UserEvent_Type = 3
+)
+
+// Enum value maps for UserEvent_Type.
+var (
+	UserEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+	}
+	UserEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x UserEvent_Type) Enum() *UserEvent_Type {
+	p := new(UserEvent_Type)
+	*p = x
+	return p
+}
+
+func (x UserEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UserEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_service_proto_enumTypes[0].Descriptor()
+}
+
+func (UserEvent_Type) Type() protoreflect.EnumType {
+	return &file_proto_service_proto_enumTypes[0]
+}
+
+func (x UserEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UserEvent_Type.Descriptor instead.
+func (UserEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{13, 0}
+}
+
 // 사용자 정보
 type User struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -153,10 +205,12 @@ func (x *GetUserRequest) GetId() int32 {
 
 // GetUser 응답
 type GetUserResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	User    *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Success bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Message string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// DeleteUser의 confirm_token으로 사용 (사용자가 변경되면 무효화됨)
+	ConfirmToken  string `protobuf:"bytes,4,opt,name=confirm_token,json=confirmToken,proto3" json:"confirm_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -212,11 +266,24 @@ func (x *GetUserResponse) GetMessage() string {
 	return ""
 }
 
+func (x *GetUserResponse) GetConfirmToken() string {
+	if x != nil {
+		return x.ConfirmToken
+	}
+	return ""
+}
+
 // ListUsers 요청
 type ListUsersRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Page  int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Limit int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// 이전 응답의 etag (일치하면 not_modified 응답)
+	IfNoneMatch string `protobuf:"bytes,3,opt,name=if_none_match,json=ifNoneMatch,proto3" json:"if_none_match,omitempty"`
+	// 이전 응답의 next_page_token (설정 시 page 대신 해당 위치부터 이어서 조회)
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// 조회할 필드 (id, name, email, age, created_at, updated_at / 비우면 전체, id는 항상 포함)
+	Fields        []string `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -265,15 +332,48 @@ func (x *ListUsersRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *ListUsersRequest) GetIfNoneMatch() string {
+	if x != nil {
+		return x.IfNoneMatch
+	}
+	return ""
+}
+
+func (x *ListUsersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListUsersRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
 // ListUsers 응답
 type ListUsersResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
-	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
-	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Users   []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Total   int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Success bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	Message string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// 결과 집합의 해시 (캐싱용)
+	Etag string `protobuf:"bytes,5,opt,name=etag,proto3" json:"etag,omitempty"`
+	// if_none_match와 etag가 일치하여 users를 생략한 경우 true
+	NotModified bool `protobuf:"varint,6,opt,name=not_modified,json=notModified,proto3" json:"not_modified,omitempty"`
+	// total이 캐시된 값(USERS_COUNT_CACHE_TTL)이거나 추정치라서 최신이 아닐 수 있으면 true
+	TotalApproximate bool `protobuf:"varint,7,opt,name=total_approximate,json=totalApproximate,proto3" json:"total_approximate,omitempty"`
+	// 다음 페이지 조회용 토큰 (응답 크기 제한으로 페이지가 잘린 경우 포함, 마지막 페이지면 빈 값)
+	NextPageToken string `protobuf:"bytes,8,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// 페이지를 읽은 DB (primary 또는 replica)
+	ServedFrom string `protobuf:"bytes,9,opt,name=served_from,json=servedFrom,proto3" json:"served_from,omitempty"`
+	// replica에서 읽은 경우 복제 지연 (초, SHOW REPLICA STATUS의 Seconds_Behind_Source, 구버전은 SHOW SLAVE STATUS / 복제본별로 5초간 캐시 / primary면 0, 알 수 없으면 -1)
+	ReplicaLagSeconds int64 `protobuf:"varint,10,opt,name=replica_lag_seconds,json=replicaLagSeconds,proto3" json:"replica_lag_seconds,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *ListUsersResponse) Reset() {
@@ -334,6 +434,48 @@ func (x *ListUsersResponse) GetMessage() string {
 	return ""
 }
 
+func (x *ListUsersResponse) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *ListUsersResponse) GetNotModified() bool {
+	if x != nil {
+		return x.NotModified
+	}
+	return false
+}
+
+func (x *ListUsersResponse) GetTotalApproximate() bool {
+	if x != nil {
+		return x.TotalApproximate
+	}
+	return false
+}
+
+func (x *ListUsersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListUsersResponse) GetServedFrom() string {
+	if x != nil {
+		return x.ServedFrom
+	}
+	return ""
+}
+
+func (x *ListUsersResponse) GetReplicaLagSeconds() int64 {
+	if x != nil {
+		return x.ReplicaLagSeconds
+	}
+	return 0
+}
+
 // CreateUser 요청
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -458,11 +600,13 @@ func (x *CreateUserResponse) GetMessage() string {
 
 // UpdateUser 요청
 type UpdateUserRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	Age           int32                  `protobuf:"varint,4,opt,name=age,proto3" json:"age,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Age   int32                  `protobuf:"varint,4,opt,name=age,proto3" json:"age,omitempty"`
+	// true이면 현재 값과 같을 때 UPDATE(및 updated_at 갱신)를 생략하고 기존 사용자를 반환
+	SkipUnchanged bool `protobuf:"varint,5,opt,name=skip_unchanged,json=skipUnchanged,proto3" json:"skip_unchanged,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -525,6 +669,13 @@ func (x *UpdateUserRequest) GetAge() int32 {
 	return 0
 }
 
+func (x *UpdateUserRequest) GetSkipUnchanged() bool {
+	if x != nil {
+		return x.SkipUnchanged
+	}
+	return false
+}
+
 // UpdateUser 응답
 type UpdateUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -586,28 +737,28 @@ func (x *UpdateUserResponse) GetMessage() string {
 	return ""
 }
 
-// DeleteUser 요청
-type DeleteUserRequest struct {
+// UpdateUsers 요청
+type UpdateUsersRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Users         []*UpdateUserRequest   `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteUserRequest) Reset() {
-	*x = DeleteUserRequest{}
+func (x *UpdateUsersRequest) Reset() {
+	*x = UpdateUsersRequest{}
 	mi := &file_proto_service_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteUserRequest) String() string {
+func (x *UpdateUsersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteUserRequest) ProtoMessage() {}
+func (*UpdateUsersRequest) ProtoMessage() {}
 
-func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateUsersRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_service_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -619,41 +770,43 @@ func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
-func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateUsersRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUsersRequest) Descriptor() ([]byte, []int) {
 	return file_proto_service_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *DeleteUserRequest) GetId() int32 {
+func (x *UpdateUsersRequest) GetUsers() []*UpdateUserRequest {
 	if x != nil {
-		return x.Id
+		return x.Users
 	}
-	return 0
+	return nil
 }
 
-// DeleteUser 응답
-type DeleteUserResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+// UpdateUsers 레코드별 결과
+type UpdateUserResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// true면 업데이트됨, false면 사용자를 찾지 못함
+	Updated       bool  `protobuf:"varint,2,opt,name=updated,proto3" json:"updated,omitempty"`
+	User          *User `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteUserResponse) Reset() {
-	*x = DeleteUserResponse{}
+func (x *UpdateUserResult) Reset() {
+	*x = UpdateUserResult{}
 	mi := &file_proto_service_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteUserResponse) String() string {
+func (x *UpdateUserResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteUserResponse) ProtoMessage() {}
+func (*UpdateUserResult) ProtoMessage() {}
 
-func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
+func (x *UpdateUserResult) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_service_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -665,84 +818,961 @@ func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
-func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateUserResult.ProtoReflect.Descriptor instead.
+func (*UpdateUserResult) Descriptor() ([]byte, []int) {
 	return file_proto_service_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *DeleteUserResponse) GetSuccess() bool {
+func (x *UpdateUserResult) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateUserResult) GetUpdated() bool {
+	if x != nil {
+		return x.Updated
+	}
+	return false
+}
+
+func (x *UpdateUserResult) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+// UpdateUsers 응답
+type UpdateUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*UpdateUserResult    `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	UpdatedCount  int32                  `protobuf:"varint,2,opt,name=updated_count,json=updatedCount,proto3" json:"updated_count,omitempty"`
+	NotFoundCount int32                  `protobuf:"varint,3,opt,name=not_found_count,json=notFoundCount,proto3" json:"not_found_count,omitempty"`
+	Success       bool                   `protobuf:"varint,4,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUsersResponse) Reset() {
+	*x = UpdateUsersResponse{}
+	mi := &file_proto_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUsersResponse) ProtoMessage() {}
+
+func (x *UpdateUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUsersResponse.ProtoReflect.Descriptor instead.
+func (*UpdateUsersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateUsersResponse) GetResults() []*UpdateUserResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *UpdateUsersResponse) GetUpdatedCount() int32 {
+	if x != nil {
+		return x.UpdatedCount
+	}
+	return 0
+}
+
+func (x *UpdateUsersResponse) GetNotFoundCount() int32 {
+	if x != nil {
+		return x.NotFoundCount
+	}
+	return 0
+}
+
+func (x *UpdateUsersResponse) GetSuccess() bool {
 	if x != nil {
 		return x.Success
 	}
 	return false
 }
 
-func (x *DeleteUserResponse) GetMessage() string {
+func (x *UpdateUsersResponse) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
 	return ""
 }
 
-var File_proto_service_proto protoreflect.FileDescriptor
+// WatchUsers 요청
+type WatchUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_proto_service_proto_rawDesc = "" +
-	"\n" +
-	"\x13proto/service.proto\x12\aservice\"\x90\x01\n" +
-	"\x04User\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12\x10\n" +
-	"\x03age\x18\x04 \x01(\x05R\x03age\x12\x1d\n" +
-	"\n" +
-	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1d\n" +
-	"\n" +
-	"updated_at\x18\x06 \x01(\tR\tupdatedAt\" \n" +
-	"\x0eGetUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\"h\n" +
-	"\x0fGetUserResponse\x12!\n" +
-	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"<\n" +
-	"\x10ListUsersRequest\x12\x12\n" +
-	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"\x82\x01\n" +
-	"\x11ListUsersResponse\x12#\n" +
-	"\x05users\x18\x01 \x03(\v2\r.service.UserR\x05users\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x18\n" +
-	"\asuccess\x18\x03 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage\"O\n" +
-	"\x11CreateUserRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\x12\x10\n" +
-	"\x03age\x18\x03 \x01(\x05R\x03age\"k\n" +
-	"\x12CreateUserResponse\x12!\n" +
-	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"_\n" +
-	"\x11UpdateUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12\x10\n" +
-	"\x03age\x18\x04 \x01(\x05R\x03age\"k\n" +
-	"\x12UpdateUserResponse\x12!\n" +
-	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"#\n" +
-	"\x11DeleteUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\"H\n" +
-	"\x12DeleteUserResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xe4\x02\n" +
-	"\vUserService\x12<\n" +
-	"\aGetUser\x12\x17.service.GetUserRequest\x1a\x18.service.GetUserResponse\x12B\n" +
-	"\tListUsers\x12\x19.service.ListUsersRequest\x1a\x1a.service.ListUsersResponse\x12E\n" +
-	"\n" +
-	"CreateUser\x12\x1a.service.CreateUserRequest\x1a\x1b.service.CreateUserResponse\x12E\n" +
-	"\n" +
-	"UpdateUser\x12\x1a.service.UpdateUserRequest\x1a\x1b.service.UpdateUserResponse\x12E\n" +
+func (x *WatchUsersRequest) Reset() {
+	*x = WatchUsersRequest{}
+	mi := &file_proto_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUsersRequest) ProtoMessage() {}
+
+func (x *WatchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUsersRequest.ProtoReflect.Descriptor instead.
+func (*WatchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{12}
+}
+
+// 사용자 변경 이벤트
+type UserEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  UserEvent_Type         `protobuf:"varint,1,opt,name=type,proto3,enum=service.UserEvent_Type" json:"type,omitempty"`
+	// DELETED 이벤트는 id만 채워짐
+	User          *User  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	OccurredAt    string `protobuf:"bytes,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserEvent) Reset() {
+	*x = UserEvent{}
+	mi := &file_proto_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserEvent) ProtoMessage() {}
+
+func (x *UserEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserEvent.ProtoReflect.Descriptor instead.
+func (*UserEvent) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserEvent) GetType() UserEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return UserEvent_TYPE_UNSPECIFIED
+}
+
+func (x *UserEvent) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UserEvent) GetOccurredAt() string {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return ""
+}
+
+// DeleteUser 요청
+type DeleteUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// 직전 GetUser 응답의 confirm_token (REQUIRE_DELETE_CONFIRM=on이면 필수, 그 사이 사용자가 변경되었으면 거부)
+	ConfirmToken  string `protobuf:"bytes,2,opt,name=confirm_token,json=confirmToken,proto3" json:"confirm_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_proto_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteUserRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteUserRequest) GetConfirmToken() string {
+	if x != nil {
+		return x.ConfirmToken
+	}
+	return ""
+}
+
+// DeleteUser 응답
+type DeleteUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserResponse) Reset() {
+	*x = DeleteUserResponse{}
+	mi := &file_proto_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserResponse) ProtoMessage() {}
+
+func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
+func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteUserResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeleteUserResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// ExportUsersCSV 요청 (ListUsers와 같은 fields/page_token 필터 적용, 페이지 크기 제한 없음)
+type ExportUsersCSVRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 내보낼 필드 (ListUsers.fields와 동일, 비우면 전체, id는 항상 포함)
+	Fields []string `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+	// ListUsers의 next_page_token (설정 시 해당 위치 이후 사용자만 내보냄)
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUsersCSVRequest) Reset() {
+	*x = ExportUsersCSVRequest{}
+	mi := &file_proto_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUsersCSVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUsersCSVRequest) ProtoMessage() {}
+
+func (x *ExportUsersCSVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUsersCSVRequest.ProtoReflect.Descriptor instead.
+func (*ExportUsersCSVRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExportUsersCSVRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *ExportUsersCSVRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// CSV 출력 조각 (모든 청크를 이어 붙이면 하나의 CSV 문서)
+type CSVChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CSVChunk) Reset() {
+	*x = CSVChunk{}
+	mi := &file_proto_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CSVChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSVChunk) ProtoMessage() {}
+
+func (x *CSVChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSVChunk.ProtoReflect.Descriptor instead.
+func (*CSVChunk) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CSVChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// ListUserIDs 요청 (ListUsers와 같은 page/limit/page_token 의미)
+type ListUserIDsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserIDsRequest) Reset() {
+	*x = ListUserIDsRequest{}
+	mi := &file_proto_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserIDsRequest) ProtoMessage() {}
+
+func (x *ListUserIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserIDsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserIDsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListUserIDsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListUserIDsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListUserIDsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// ListUserIDs 응답
+type ListUserIDsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ids   []int32                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	// 다음 페이지가 있을 수 있으면 설정 (ListUsers의 page_token으로도 사용 가능)
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserIDsResponse) Reset() {
+	*x = ListUserIDsResponse{}
+	mi := &file_proto_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserIDsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserIDsResponse) ProtoMessage() {}
+
+func (x *ListUserIDsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserIDsResponse.ProtoReflect.Descriptor instead.
+func (*ListUserIDsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListUserIDsResponse) GetIds() []int32 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *ListUserIDsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ServerInfo 요청
+type ServerInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerInfoRequest) Reset() {
+	*x = ServerInfoRequest{}
+	mi := &file_proto_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfoRequest) ProtoMessage() {}
+
+func (x *ServerInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfoRequest.ProtoReflect.Descriptor instead.
+func (*ServerInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{20}
+}
+
+// ServerInfo 응답 (주입되지 않은 값은 version=dev, 나머지는 unknown)
+type ServerInfoResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Version   string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	BuildTime string                 `protobuf:"bytes,2,opt,name=build_time,json=buildTime,proto3" json:"build_time,omitempty"`
+	GitCommit string                 `protobuf:"bytes,3,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`
+	// 사용 중인 분산 락 타입 (redis 또는 etcd)
+	LockType      string `protobuf:"bytes,4,opt,name=lock_type,json=lockType,proto3" json:"lock_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerInfoResponse) Reset() {
+	*x = ServerInfoResponse{}
+	mi := &file_proto_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfoResponse) ProtoMessage() {}
+
+func (x *ServerInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfoResponse.ProtoReflect.Descriptor instead.
+func (*ServerInfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ServerInfoResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServerInfoResponse) GetBuildTime() string {
+	if x != nil {
+		return x.BuildTime
+	}
+	return ""
+}
+
+func (x *ServerInfoResponse) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
+}
+
+func (x *ServerInfoResponse) GetLockType() string {
+	if x != nil {
+		return x.LockType
+	}
+	return ""
+}
+
+// UserStats 요청
+type UserStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 나이 구간 경계 (오름차순, 예: [20, 40] → 0~19, 20~39, 40~150 / 비우면 10살 단위)
+	AgeBounds     []int32 `protobuf:"varint,1,rep,packed,name=age_bounds,json=ageBounds,proto3" json:"age_bounds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserStatsRequest) Reset() {
+	*x = UserStatsRequest{}
+	mi := &file_proto_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatsRequest) ProtoMessage() {}
+
+func (x *UserStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatsRequest.ProtoReflect.Descriptor instead.
+func (*UserStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UserStatsRequest) GetAgeBounds() []int32 {
+	if x != nil {
+		return x.AgeBounds
+	}
+	return nil
+}
+
+// 나이 구간 (min_age, max_age 모두 포함)
+type AgeBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinAge        int32                  `protobuf:"varint,1,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	MaxAge        int32                  `protobuf:"varint,2,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	Count         int64                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgeBucket) Reset() {
+	*x = AgeBucket{}
+	mi := &file_proto_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgeBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgeBucket) ProtoMessage() {}
+
+func (x *AgeBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgeBucket.ProtoReflect.Descriptor instead.
+func (*AgeBucket) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AgeBucket) GetMinAge() int32 {
+	if x != nil {
+		return x.MinAge
+	}
+	return 0
+}
+
+func (x *AgeBucket) GetMaxAge() int32 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *AgeBucket) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// UserStats 응답 (사용자가 없으면 min_age, max_age, avg_age는 0)
+type UserStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []*AgeBucket           `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	MinAge        int32                  `protobuf:"varint,3,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	MaxAge        int32                  `protobuf:"varint,4,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	AvgAge        float64                `protobuf:"fixed64,5,opt,name=avg_age,json=avgAge,proto3" json:"avg_age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserStatsResponse) Reset() {
+	*x = UserStatsResponse{}
+	mi := &file_proto_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatsResponse) ProtoMessage() {}
+
+func (x *UserStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatsResponse.ProtoReflect.Descriptor instead.
+func (*UserStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UserStatsResponse) GetBuckets() []*AgeBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *UserStatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetMinAge() int32 {
+	if x != nil {
+		return x.MinAge
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetMaxAge() int32 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetAvgAge() float64 {
+	if x != nil {
+		return x.AvgAge
+	}
+	return 0
+}
+
+var File_proto_service_proto protoreflect.FileDescriptor
+
+const file_proto_service_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/service.proto\x12\aservice\"\x90\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x10\n" +
+	"\x03age\x18\x04 \x01(\x05R\x03age\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\tR\tupdatedAt\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"\x8d\x01\n" +
+	"\x0fGetUserResponse\x12!\n" +
+	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12#\n" +
+	"\rconfirm_token\x18\x04 \x01(\tR\fconfirmToken\"\x97\x01\n" +
+	"\x10ListUsersRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\"\n" +
+	"\rif_none_match\x18\x03 \x01(\tR\vifNoneMatch\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06fields\x18\x05 \x03(\tR\x06fields\"\xdf\x02\n" +
+	"\x11ListUsersResponse\x12#\n" +
+	"\x05users\x18\x01 \x03(\v2\r.service.UserR\x05users\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x12\n" +
+	"\x04etag\x18\x05 \x01(\tR\x04etag\x12!\n" +
+	"\fnot_modified\x18\x06 \x01(\bR\vnotModified\x12+\n" +
+	"\x11total_approximate\x18\a \x01(\bR\x10totalApproximate\x12&\n" +
+	"\x0fnext_page_token\x18\b \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vserved_from\x18\t \x01(\tR\n" +
+	"servedFrom\x12.\n" +
+	"\x13replica_lag_seconds\x18\n" +
+	" \x01(\x03R\x11replicaLagSeconds\"O\n" +
+	"\x11CreateUserRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x10\n" +
+	"\x03age\x18\x03 \x01(\x05R\x03age\"k\n" +
+	"\x12CreateUserResponse\x12!\n" +
+	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x86\x01\n" +
+	"\x11UpdateUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x10\n" +
+	"\x03age\x18\x04 \x01(\x05R\x03age\x12%\n" +
+	"\x0eskip_unchanged\x18\x05 \x01(\bR\rskipUnchanged\"k\n" +
+	"\x12UpdateUserResponse\x12!\n" +
+	"\x04user\x18\x01 \x01(\v2\r.service.UserR\x04user\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"F\n" +
+	"\x12UpdateUsersRequest\x120\n" +
+	"\x05users\x18\x01 \x03(\v2\x1a.service.UpdateUserRequestR\x05users\"_\n" +
+	"\x10UpdateUserResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x18\n" +
+	"\aupdated\x18\x02 \x01(\bR\aupdated\x12!\n" +
+	"\x04user\x18\x03 \x01(\v2\r.service.UserR\x04user\"\xcb\x01\n" +
+	"\x13UpdateUsersResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.service.UpdateUserResultR\aresults\x12#\n" +
+	"\rupdated_count\x18\x02 \x01(\x05R\fupdatedCount\x12&\n" +
+	"\x0fnot_found_count\x18\x03 \x01(\x05R\rnotFoundCount\x12\x18\n" +
+	"\asuccess\x18\x04 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\"\x13\n" +
+	"\x11WatchUsersRequest\"\xc1\x01\n" +
+	"\tUserEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.service.UserEvent.TypeR\x04type\x12!\n" +
+	"\x04user\x18\x02 \x01(\v2\r.service.UserR\x04user\x12\x1f\n" +
+	"\voccurred_at\x18\x03 \x01(\tR\n" +
+	"occurredAt\"C\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x03\"H\n" +
+	"\x11DeleteUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12#\n" +
+	"\rconfirm_token\x18\x02 \x01(\tR\fconfirmToken\"H\n" +
+	"\x12DeleteUserResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"N\n" +
+	"\x15ExportUsersCSVRequest\x12\x16\n" +
+	"\x06fields\x18\x01 \x03(\tR\x06fields\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\x1e\n" +
+	"\bCSVChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"]\n" +
+	"\x12ListUserIDsRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"O\n" +
+	"\x13ListUserIDsResponse\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x05R\x03ids\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x13\n" +
+	"\x11ServerInfoRequest\"\x89\x01\n" +
+	"\x12ServerInfoResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"build_time\x18\x02 \x01(\tR\tbuildTime\x12\x1d\n" +
+	"\n" +
+	"git_commit\x18\x03 \x01(\tR\tgitCommit\x12\x1b\n" +
+	"\tlock_type\x18\x04 \x01(\tR\blockType\"1\n" +
+	"\x10UserStatsRequest\x12\x1d\n" +
+	"\n" +
+	"age_bounds\x18\x01 \x03(\x05R\tageBounds\"S\n" +
+	"\tAgeBucket\x12\x17\n" +
+	"\amin_age\x18\x01 \x01(\x05R\x06minAge\x12\x17\n" +
+	"\amax_age\x18\x02 \x01(\x05R\x06maxAge\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\"\xa2\x01\n" +
+	"\x11UserStatsResponse\x12,\n" +
+	"\abuckets\x18\x01 \x03(\v2\x12.service.AgeBucketR\abuckets\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x17\n" +
+	"\amin_age\x18\x03 \x01(\x05R\x06minAge\x12\x17\n" +
+	"\amax_age\x18\x04 \x01(\x05R\x06maxAge\x12\x17\n" +
+	"\aavg_age\x18\x05 \x01(\x01R\x06avgAge2\x8a\x06\n" +
+	"\vUserService\x12<\n" +
+	"\aGetUser\x12\x17.service.GetUserRequest\x1a\x18.service.GetUserResponse\x12B\n" +
+	"\tListUsers\x12\x19.service.ListUsersRequest\x1a\x1a.service.ListUsersResponse\x12E\n" +
+	"\n" +
+	"CreateUser\x12\x1a.service.CreateUserRequest\x1a\x1b.service.CreateUserResponse\x12E\n" +
+	"\n" +
+	"UpdateUser\x12\x1a.service.UpdateUserRequest\x1a\x1b.service.UpdateUserResponse\x12E\n" +
+	"\n" +
+	"DeleteUser\x12\x1a.service.DeleteUserRequest\x1a\x1b.service.DeleteUserResponse\x12H\n" +
+	"\vUpdateUsers\x12\x1b.service.UpdateUsersRequest\x1a\x1c.service.UpdateUsersResponse\x12>\n" +
+	"\n" +
+	"WatchUsers\x12\x1a.service.WatchUsersRequest\x1a\x12.service.UserEvent0\x01\x12H\n" +
+	"\vListUserIDs\x12\x1b.service.ListUserIDsRequest\x1a\x1c.service.ListUserIDsResponse\x12E\n" +
+	"\x0eExportUsersCSV\x12\x1e.service.ExportUsersCSVRequest\x1a\x11.service.CSVChunk0\x01\x12E\n" +
 	"\n" +
-	"DeleteUser\x12\x1a.service.DeleteUserRequest\x1a\x1b.service.DeleteUserResponseB\x1dZ\x1bgo-grpc-server-client/protob\x06proto3"
+	"ServerInfo\x12\x1a.service.ServerInfoRequest\x1a\x1b.service.ServerInfoResponse\x12B\n" +
+	"\tUserStats\x12\x19.service.UserStatsRequest\x1a\x1a.service.UserStatsResponseB\x1dZ\x1bgo-grpc-server-client/protob\x06proto3"
 
 var (
 	file_proto_service_proto_rawDescOnce sync.Once
@@ -756,40 +1786,74 @@ func file_proto_service_proto_rawDescGZIP() []byte {
 	return file_proto_service_proto_rawDescData
 }
 
-var file_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_proto_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
 var file_proto_service_proto_goTypes = []any{
-	(*User)(nil),               // 0: service.User
-	(*GetUserRequest)(nil),     // 1: service.GetUserRequest
-	(*GetUserResponse)(nil),    // 2: service.GetUserResponse
-	(*ListUsersRequest)(nil),   // 3: service.ListUsersRequest
-	(*ListUsersResponse)(nil),  // 4: service.ListUsersResponse
-	(*CreateUserRequest)(nil),  // 5: service.CreateUserRequest
-	(*CreateUserResponse)(nil), // 6: service.CreateUserResponse
-	(*UpdateUserRequest)(nil),  // 7: service.UpdateUserRequest
-	(*UpdateUserResponse)(nil), // 8: service.UpdateUserResponse
-	(*DeleteUserRequest)(nil),  // 9: service.DeleteUserRequest
-	(*DeleteUserResponse)(nil), // 10: service.DeleteUserResponse
+	(UserEvent_Type)(0),           // 0: service.UserEvent.Type
+	(*User)(nil),                  // 1: service.User
+	(*GetUserRequest)(nil),        // 2: service.GetUserRequest
+	(*GetUserResponse)(nil),       // 3: service.GetUserResponse
+	(*ListUsersRequest)(nil),      // 4: service.ListUsersRequest
+	(*ListUsersResponse)(nil),     // 5: service.ListUsersResponse
+	(*CreateUserRequest)(nil),     // 6: service.CreateUserRequest
+	(*CreateUserResponse)(nil),    // 7: service.CreateUserResponse
+	(*UpdateUserRequest)(nil),     // 8: service.UpdateUserRequest
+	(*UpdateUserResponse)(nil),    // 9: service.UpdateUserResponse
+	(*UpdateUsersRequest)(nil),    // 10: service.UpdateUsersRequest
+	(*UpdateUserResult)(nil),      // 11: service.UpdateUserResult
+	(*UpdateUsersResponse)(nil),   // 12: service.UpdateUsersResponse
+	(*WatchUsersRequest)(nil),     // 13: service.WatchUsersRequest
+	(*UserEvent)(nil),             // 14: service.UserEvent
+	(*DeleteUserRequest)(nil),     // 15: service.DeleteUserRequest
+	(*DeleteUserResponse)(nil),    // 16: service.DeleteUserResponse
+	(*ExportUsersCSVRequest)(nil), // 17: service.ExportUsersCSVRequest
+	(*CSVChunk)(nil),              // 18: service.CSVChunk
+	(*ListUserIDsRequest)(nil),    // 19: service.ListUserIDsRequest
+	(*ListUserIDsResponse)(nil),   // 20: service.ListUserIDsResponse
+	(*ServerInfoRequest)(nil),     // 21: service.ServerInfoRequest
+	(*ServerInfoResponse)(nil),    // 22: service.ServerInfoResponse
+	(*UserStatsRequest)(nil),      // 23: service.UserStatsRequest
+	(*AgeBucket)(nil),             // 24: service.AgeBucket
+	(*UserStatsResponse)(nil),     // 25: service.UserStatsResponse
 }
 var file_proto_service_proto_depIdxs = []int32{
-	0,  // 0: service.GetUserResponse.user:type_name -> service.User
-	0,  // 1: service.ListUsersResponse.users:type_name -> service.User
-	0,  // 2: service.CreateUserResponse.user:type_name -> service.User
-	0,  // 3: service.UpdateUserResponse.user:type_name -> service.User
-	1,  // 4: service.UserService.GetUser:input_type -> service.GetUserRequest
-	3,  // 5: service.UserService.ListUsers:input_type -> service.ListUsersRequest
-	5,  // 6: service.UserService.CreateUser:input_type -> service.CreateUserRequest
-	7,  // 7: service.UserService.UpdateUser:input_type -> service.UpdateUserRequest
-	9,  // 8: service.UserService.DeleteUser:input_type -> service.DeleteUserRequest
-	2,  // 9: service.UserService.GetUser:output_type -> service.GetUserResponse
-	4,  // 10: service.UserService.ListUsers:output_type -> service.ListUsersResponse
-	6,  // 11: service.UserService.CreateUser:output_type -> service.CreateUserResponse
-	8,  // 12: service.UserService.UpdateUser:output_type -> service.UpdateUserResponse
-	10, // 13: service.UserService.DeleteUser:output_type -> service.DeleteUserResponse
-	9,  // [9:14] is the sub-list for method output_type
-	4,  // [4:9] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	1,  // 0: service.GetUserResponse.user:type_name -> service.User
+	1,  // 1: service.ListUsersResponse.users:type_name -> service.User
+	1,  // 2: service.CreateUserResponse.user:type_name -> service.User
+	1,  // 3: service.UpdateUserResponse.user:type_name -> service.User
+	8,  // 4: service.UpdateUsersRequest.users:type_name -> service.UpdateUserRequest
+	1,  // 5: service.UpdateUserResult.user:type_name -> service.User
+	11, // 6: service.UpdateUsersResponse.results:type_name -> service.UpdateUserResult
+	0,  // 7: service.UserEvent.type:type_name -> service.UserEvent.Type
+	1,  // 8: service.UserEvent.user:type_name -> service.User
+	24, // 9: service.UserStatsResponse.buckets:type_name -> service.AgeBucket
+	2,  // 10: service.UserService.GetUser:input_type -> service.GetUserRequest
+	4,  // 11: service.UserService.ListUsers:input_type -> service.ListUsersRequest
+	6,  // 12: service.UserService.CreateUser:input_type -> service.CreateUserRequest
+	8,  // 13: service.UserService.UpdateUser:input_type -> service.UpdateUserRequest
+	15, // 14: service.UserService.DeleteUser:input_type -> service.DeleteUserRequest
+	10, // 15: service.UserService.UpdateUsers:input_type -> service.UpdateUsersRequest
+	13, // 16: service.UserService.WatchUsers:input_type -> service.WatchUsersRequest
+	19, // 17: service.UserService.ListUserIDs:input_type -> service.ListUserIDsRequest
+	17, // 18: service.UserService.ExportUsersCSV:input_type -> service.ExportUsersCSVRequest
+	21, // 19: service.UserService.ServerInfo:input_type -> service.ServerInfoRequest
+	23, // 20: service.UserService.UserStats:input_type -> service.UserStatsRequest
+	3,  // 21: service.UserService.GetUser:output_type -> service.GetUserResponse
+	5,  // 22: service.UserService.ListUsers:output_type -> service.ListUsersResponse
+	7,  // 23: service.UserService.CreateUser:output_type -> service.CreateUserResponse
+	9,  // 24: service.UserService.UpdateUser:output_type -> service.UpdateUserResponse
+	16, // 25: service.UserService.DeleteUser:output_type -> service.DeleteUserResponse
+	12, // 26: service.UserService.UpdateUsers:output_type -> service.UpdateUsersResponse
+	14, // 27: service.UserService.WatchUsers:output_type -> service.UserEvent
+	20, // 28: service.UserService.ListUserIDs:output_type -> service.ListUserIDsResponse
+	18, // 29: service.UserService.ExportUsersCSV:output_type -> service.CSVChunk
+	22, // 30: service.UserService.ServerInfo:output_type -> service.ServerInfoResponse
+	25, // 31: service.UserService.UserStats:output_type -> service.UserStatsResponse
+	21, // [21:32] is the sub-list for method output_type
+	10, // [10:21] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_service_proto_init() }
@@ -802,13 +1866,14 @@ func file_proto_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_service_proto_rawDesc), len(file_proto_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_service_proto_goTypes,
 		DependencyIndexes: file_proto_service_proto_depIdxs,
+		EnumInfos:         file_proto_service_proto_enumTypes,
 		MessageInfos:      file_proto_service_proto_msgTypes,
 	}.Build()
 	File_proto_service_proto = out.File
@@ -19,11 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UserService_GetUser_FullMethodName    = "/service.UserService/GetUser"
-	UserService_ListUsers_FullMethodName  = "/service.UserService/ListUsers"
-	UserService_CreateUser_FullMethodName = "/service.UserService/CreateUser"
-	UserService_UpdateUser_FullMethodName = "/service.UserService/UpdateUser"
-	UserService_DeleteUser_FullMethodName = "/service.UserService/DeleteUser"
+	UserService_GetUser_FullMethodName        = "/service.UserService/GetUser"
+	UserService_ListUsers_FullMethodName      = "/service.UserService/ListUsers"
+	UserService_CreateUser_FullMethodName     = "/service.UserService/CreateUser"
+	UserService_UpdateUser_FullMethodName     = "/service.UserService/UpdateUser"
+	UserService_DeleteUser_FullMethodName     = "/service.UserService/DeleteUser"
+	UserService_UpdateUsers_FullMethodName    = "/service.UserService/UpdateUsers"
+	UserService_WatchUsers_FullMethodName     = "/service.UserService/WatchUsers"
+	UserService_ListUserIDs_FullMethodName    = "/service.UserService/ListUserIDs"
+	UserService_ExportUsersCSV_FullMethodName = "/service.UserService/ExportUsersCSV"
+	UserService_ServerInfo_FullMethodName     = "/service.UserService/ServerInfo"
+	UserService_UserStats_FullMethodName      = "/service.UserService/UserStats"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -42,6 +48,20 @@ type UserServiceClient interface {
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UpdateUserResponse, error)
 	// 사용자 삭제
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	// 여러 사용자 일괄 업데이트 (단일 트랜잭션)
+	UpdateUsers(ctx context.Context, in *UpdateUsersRequest, opts ...grpc.CallOption) (*UpdateUsersResponse, error)
+	// 사용자 생성/수정/삭제 이벤트 구독 (서버 스트리밍)
+	// 전달 보장은 at-most-once: 구독 중에 커밋된 변경만 전달되며 재연결 시 놓친 이벤트는 재전송되지 않음
+	// 이벤트를 제때 읽지 못해 버퍼가 가득 찬 구독자는 RESOURCE_EXHAUSTED로 연결이 끊김
+	WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserEvent], error)
+	// 사용자 ID 목록 조회 (ListUsers와 같은 페이지 조건, 전체 레코드보다 훨씬 가벼움)
+	ListUserIDs(ctx context.Context, in *ListUserIDsRequest, opts ...grpc.CallOption) (*ListUserIDsResponse, error)
+	// 사용자 목록 CSV 내보내기 (서버 스트리밍, 헤더 행 포함, 전체를 버퍼링하지 않고 청크 단위로 전송)
+	ExportUsersCSV(ctx context.Context, in *ExportUsersCSVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CSVChunk], error)
+	// 서버 빌드 정보 조회 (버전/빌드 시각/커밋은 빌드 시 ldflags로 주입)
+	ServerInfo(ctx context.Context, in *ServerInfoRequest, opts ...grpc.CallOption) (*ServerInfoResponse, error)
+	// 나이 구간별 사용자 수와 나이 요약 통계 조회 (집계는 DB의 GROUP BY로 수행)
+	UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error)
 }
 
 type userServiceClient struct {
@@ -102,6 +122,84 @@ func (c *userServiceClient) DeleteUser(ctx context.Context, in *DeleteUserReques
 	return out, nil
 }
 
+func (c *userServiceClient) UpdateUsers(ctx context.Context, in *UpdateUsersRequest, opts ...grpc.CallOption) (*UpdateUsersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateUsersResponse)
+	err := c.cc.Invoke(ctx, UserService_UpdateUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[0], UserService_WatchUsers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchUsersRequest, UserEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_WatchUsersClient = grpc.ServerStreamingClient[UserEvent]
+
+func (c *userServiceClient) ListUserIDs(ctx context.Context, in *ListUserIDsRequest, opts ...grpc.CallOption) (*ListUserIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUserIDsResponse)
+	err := c.cc.Invoke(ctx, UserService_ListUserIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ExportUsersCSV(ctx context.Context, in *ExportUsersCSVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CSVChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[1], UserService_ExportUsersCSV_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportUsersCSVRequest, CSVChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_ExportUsersCSVClient = grpc.ServerStreamingClient[CSVChunk]
+
+func (c *userServiceClient) ServerInfo(ctx context.Context, in *ServerInfoRequest, opts ...grpc.CallOption) (*ServerInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServerInfoResponse)
+	err := c.cc.Invoke(ctx, UserService_ServerInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserStatsResponse)
+	err := c.cc.Invoke(ctx, UserService_UserStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
@@ -118,6 +216,20 @@ type UserServiceServer interface {
 	UpdateUser(context.Context, *UpdateUserRequest) (*UpdateUserResponse, error)
 	// 사용자 삭제
 	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	// 여러 사용자 일괄 업데이트 (단일 트랜잭션)
+	UpdateUsers(context.Context, *UpdateUsersRequest) (*UpdateUsersResponse, error)
+	// 사용자 생성/수정/삭제 이벤트 구독 (서버 스트리밍)
+	// 전달 보장은 at-most-once: 구독 중에 커밋된 변경만 전달되며 재연결 시 놓친 이벤트는 재전송되지 않음
+	// 이벤트를 제때 읽지 못해 버퍼가 가득 찬 구독자는 RESOURCE_EXHAUSTED로 연결이 끊김
+	WatchUsers(*WatchUsersRequest, grpc.ServerStreamingServer[UserEvent]) error
+	// 사용자 ID 목록 조회 (ListUsers와 같은 페이지 조건, 전체 레코드보다 훨씬 가벼움)
+	ListUserIDs(context.Context, *ListUserIDsRequest) (*ListUserIDsResponse, error)
+	// 사용자 목록 CSV 내보내기 (서버 스트리밍, 헤더 행 포함, 전체를 버퍼링하지 않고 청크 단위로 전송)
+	ExportUsersCSV(*ExportUsersCSVRequest, grpc.ServerStreamingServer[CSVChunk]) error
+	// 서버 빌드 정보 조회 (버전/빌드 시각/커밋은 빌드 시 ldflags로 주입)
+	ServerInfo(context.Context, *ServerInfoRequest) (*ServerInfoResponse, error)
+	// 나이 구간별 사용자 수와 나이 요약 통계 조회 (집계는 DB의 GROUP BY로 수행)
+	UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -143,6 +255,24 @@ func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserReq
 func (UnimplementedUserServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUserServiceServer) UpdateUsers(context.Context, *UpdateUsersRequest) (*UpdateUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUsers not implemented")
+}
+func (UnimplementedUserServiceServer) WatchUsers(*WatchUsersRequest, grpc.ServerStreamingServer[UserEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUsers not implemented")
+}
+func (UnimplementedUserServiceServer) ListUserIDs(context.Context, *ListUserIDsRequest) (*ListUserIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserIDs not implemented")
+}
+func (UnimplementedUserServiceServer) ExportUsersCSV(*ExportUsersCSVRequest, grpc.ServerStreamingServer[CSVChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUsersCSV not implemented")
+}
+func (UnimplementedUserServiceServer) ServerInfo(context.Context, *ServerInfoRequest) (*ServerInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ServerInfo not implemented")
+}
+func (UnimplementedUserServiceServer) UserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserStats not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -254,6 +384,100 @@ func _UserService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_UpdateUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UpdateUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateUsers(ctx, req.(*UpdateUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_WatchUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUsersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).WatchUsers(m, &grpc.GenericServerStream[WatchUsersRequest, UserEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_WatchUsersServer = grpc.ServerStreamingServer[UserEvent]
+
+func _UserService_ListUserIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUserIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListUserIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUserIDs(ctx, req.(*ListUserIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ExportUsersCSV_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUsersCSVRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).ExportUsersCSV(m, &grpc.GenericServerStream[ExportUsersCSVRequest, CSVChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_ExportUsersCSVServer = grpc.ServerStreamingServer[CSVChunk]
+
+func _UserService_ServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ServerInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ServerInfo(ctx, req.(*ServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UserStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UserStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UserStats(ctx, req.(*UserStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -281,7 +505,34 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _UserService_DeleteUser_Handler,
 		},
+		{
+			MethodName: "UpdateUsers",
+			Handler:    _UserService_UpdateUsers_Handler,
+		},
+		{
+			MethodName: "ListUserIDs",
+			Handler:    _UserService_ListUserIDs_Handler,
+		},
+		{
+			MethodName: "ServerInfo",
+			Handler:    _UserService_ServerInfo_Handler,
+		},
+		{
+			MethodName: "UserStats",
+			Handler:    _UserService_UserStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUsers",
+			Handler:       _UserService_WatchUsers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportUsersCSV",
+			Handler:       _UserService_ExportUsersCSV_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/service.proto",
 }
@@ -98,7 +98,8 @@ func setupTestEnvironment(t testing.TB) *TestEnvironment {
 	require.NoError(t, err)
 
 	grpcServer := grpc.NewServer()
-	userServer := server.NewUserServer(mysqlDSN, "redis", redisAddr, "")
+	userServer, err := server.NewUserServer(mysqlDSN, "redis", redisAddr, "")
+	require.NoError(t, err)
 	pb.RegisterUserServiceServer(grpcServer, userServer)
 
 	go func() {
@@ -284,6 +285,11 @@ func TestIntegration_ListUsers(t *testing.T) {
 	assert.True(t, userNames["Alice"])
 	assert.True(t, userNames["Bob"])
 	assert.True(t, userNames["Charlie"])
+
+	// Results come back in ascending ID order
+	for i := 1; i < len(listResp.Users); i++ {
+		assert.Less(t, listResp.Users[i-1].Id, listResp.Users[i].Id)
+	}
 }
 
 func TestIntegration_ConcurrentUserOperations(t *testing.T) {